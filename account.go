@@ -4,8 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-
-	"github.com/pkg/errors"
 )
 
 type CreateAccountParams struct {
@@ -21,6 +19,10 @@ type CreateAccountParams struct {
 // On success, returns an Account object with the regular fields and an additional access_token field.
 // https://telegra.ph/api#createAccount
 func (c *Client) CreateAccount(ctx context.Context, shortName string, params *CreateAccountParams, opts ...RequestOption) (*Account, error) {
+	if err := params.validate(shortName); err != nil {
+		return nil, err
+	}
+
 	r := &request{
 		method:   http.MethodPost,
 		endpoint: "createAccount",
@@ -47,7 +49,7 @@ func (c *Client) CreateAccount(ctx context.Context, shortName string, params *Cr
 	}
 
 	if !acc.OK {
-		return acc.Result, errors.New(acc.Error)
+		return acc.Result, newAPIError(r.endpoint, acc.Error)
 	}
 
 	return acc.Result, nil
@@ -66,6 +68,10 @@ type EditAccountInfoParams struct {
 // Pass only the parameters that you want to edit. On success, returns an Account object with the default fields.
 // https://telegra.ph/api#editAccountInfo
 func (c *Client) EditAccountInfo(ctx context.Context, params *EditAccountInfoParams, opts ...RequestOption) (*Account, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
 	r := &request{
 		method:   http.MethodPost,
 		endpoint: "editAccountInfo",
@@ -92,7 +98,7 @@ func (c *Client) EditAccountInfo(ctx context.Context, params *EditAccountInfoPar
 		return nil, err
 	}
 	if !acc.OK {
-		return nil, errors.New(acc.Error)
+		return nil, newAPIError(r.endpoint, acc.Error)
 	}
 	return acc.Result, nil
 }
@@ -107,9 +113,10 @@ type GetAccountInfoOption struct {
 // https://telegra.ph/api#getAccountInfo
 func (c *Client) GetAccountInfo(ctx context.Context, option *GetAccountInfoOption, opts ...RequestOption) (*Account, error) {
 	r := &request{
-		method:   http.MethodPost,
-		endpoint: "getAccountInfo",
-		secured:  true,
+		method:    http.MethodPost,
+		endpoint:  "getAccountInfo",
+		retryable: true,
+		secured:   true,
 	}
 	if option != nil {
 		if len(option.Fields) > 0 {
@@ -129,7 +136,7 @@ func (c *Client) GetAccountInfo(ctx context.Context, option *GetAccountInfoOptio
 		return nil, err
 	}
 	if !acc.OK {
-		return nil, errors.New(acc.Error)
+		return nil, newAPIError(r.endpoint, acc.Error)
 	}
 	return acc.Result, nil
 }
@@ -155,7 +162,7 @@ func (c *Client) RevokeAccessToken(ctx context.Context, opts ...RequestOption) (
 		return nil, err
 	}
 	if !acc.OK {
-		return nil, errors.New(acc.Error)
+		return nil, newAPIError(r.endpoint, acc.Error)
 	}
 	return acc.Result, nil
 }
@@ -0,0 +1,83 @@
+package telegraph
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultURLSchemes lists the URL schemes accepted in href and src attributes by ContentFormat, and by
+// ContentFormatWithOptions when WithURLSchemes is not given. Relative URLs are always accepted.
+var DefaultURLSchemes = []string{"http", "https", "mailto", "tg"}
+
+// WithBaseURL makes ContentFormatWithOptions resolve relative href and src attributes against base.
+func WithBaseURL(base *url.URL) ContentOption {
+	return contentOptionFunc(func(o *contentOptions) {
+		o.baseURL = base
+	})
+}
+
+// WithURLSchemes replaces the URL schemes accepted by ContentFormatWithOptions in href and src attributes.
+// Attributes with any other scheme, such as javascript: or data:, are dropped.
+func WithURLSchemes(schemes ...string) ContentOption {
+	return contentOptionFunc(func(o *contentOptions) {
+		o.schemes = schemeSet(schemes)
+	})
+}
+
+func schemeSet(schemes []string) map[string]bool {
+	set := make(map[string]bool, len(schemes))
+	for _, scheme := range schemes {
+		set[strings.ToLower(scheme)] = true
+	}
+	return set
+}
+
+// attrs collects the allowed attributes of an element, resolving and checking URLs.
+func (o *contentOptions) attrs(attrs []html.Attribute) map[string]string {
+	var collected map[string]string
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if !IsAllowedAttr(key) {
+			continue
+		}
+		value, ok := o.safeURL(attr.Val)
+		if !ok {
+			continue
+		}
+		if collected == nil {
+			collected = make(map[string]string, len(attrs))
+		}
+		collected[key] = value
+	}
+	return collected
+}
+
+// safeURL resolves raw against the base URL and reports whether its scheme is accepted.
+func (o *contentOptions) safeURL(raw string) (string, bool) {
+	// Browsers ignore surrounding spaces and control characters, and tabs and newlines anywhere,
+	// so "java\tscript:" must be seen as "javascript:".
+	raw = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, raw)
+	raw = strings.TrimFunc(raw, func(r rune) bool {
+		return r <= ' '
+	})
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme == "" && o.baseURL != nil {
+		u = o.baseURL.ResolveReference(u)
+	}
+	if u.Scheme != "" && !o.schemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+
+	return u.String(), true
+}
@@ -0,0 +1,222 @@
+package telegraph
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// figureTags are the tags of the elements a figure may hold.
+var figureTags = map[string]bool{"img": true, "video": true, "iframe": true, "figcaption": true}
+
+// NewElement builds an element with a tag known at run time and checks it like Validate. The
+// constructors below build elements with a fixed tag and do not check them.
+func NewElement(tag string, attrs map[string]string, children ...Node) (*NodeElement, error) {
+	element := newElement(strings.ToLower(tag), nil, children)
+	for key, value := range attrs {
+		if element.Attrs == nil {
+			element.Attrs = make(map[string]string, len(attrs))
+		}
+		element.Attrs[strings.ToLower(key)] = value
+	}
+	if err := validateNode(element, ""); err != nil {
+		return nil, err
+	}
+	return element, nil
+}
+
+// Validate checks that nodes can be sent to Telegraph: every node is a string or an element, as a
+// *NodeElement, a NodeElement or a map node such as those returned by GetPage, with a tag and attributes
+// accepted by Telegraph. Void elements (br, hr, img) must have no children, lists must only hold li
+// elements and figures img, video, iframe and figcaption elements. The first invalid node is reported.
+func Validate(nodes []Node) error {
+	for _, n := range nodes {
+		if err := validateNode(n, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNode checks n, a child of an element with tag parent or a top-level node if parent is empty.
+func validateNode(n Node, parent string) error {
+	in := ""
+	if parent != "" {
+		in = " in " + parent
+	}
+	isList := parent == "ul" || parent == "ol"
+
+	if text, ok := n.(string); ok {
+		if (isList || parent == "figure") && strings.TrimSpace(text) != "" {
+			return errors.Wrapf(ErrInvalidDataType, "text%s", in)
+		}
+		return nil
+	}
+
+	element, ok := asElement(n)
+	if !ok {
+		return errors.Wrapf(ErrInvalidDataType, "node of type %T%s", n, in)
+	}
+	tag := strings.ToLower(element.Tag)
+	switch {
+	case !IsAllowedTag(tag):
+		return errors.Wrapf(ErrTagNotAllowed, "element %q%s", element.Tag, in)
+	case isList && tag != "li":
+		return errors.Wrapf(ErrTagNotAllowed, "element %q%s, expected li", element.Tag, in)
+	case parent == "figure" && !figureTags[tag]:
+		return errors.Wrapf(ErrTagNotAllowed, "element %q%s, expected img, video, iframe or figcaption", element.Tag, in)
+	}
+	for key := range element.Attrs {
+		if !IsAllowedAttr(key) {
+			return errors.Wrapf(ErrAttrNotAllowed, "attribute %q of %s", key, tag)
+		}
+	}
+
+	if voidTags[tag] && len(element.Children) > 0 {
+		return errors.Wrapf(ErrInvalidDataType, "children of void element %s", tag)
+	}
+	for _, child := range element.Children {
+		if err := validateNode(child, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newElement builds an element without checking it.
+func newElement(tag string, attrs map[string]string, children []Node) *NodeElement {
+	element := &NodeElement{Tag: tag, Attrs: attrs}
+	if len(children) > 0 {
+		element.Children = Nodes(children)
+	}
+	return element
+}
+
+// P builds a paragraph. Like the other constructors, it does not check its children: use Validate on the
+// built content when it holds nodes from elsewhere.
+func P(children ...Node) *NodeElement {
+	return newElement("p", nil, children)
+}
+
+// H3 builds a heading, the larger one supported by Telegraph.
+func H3(children ...Node) *NodeElement {
+	return newElement("h3", nil, children)
+}
+
+// H4 builds a subheading.
+func H4(children ...Node) *NodeElement {
+	return newElement("h4", nil, children)
+}
+
+// A builds a link to href.
+func A(href string, children ...Node) *NodeElement {
+	return newElement("a", map[string]string{"href": href}, children)
+}
+
+// B builds bold text.
+func B(children ...Node) *NodeElement {
+	return newElement("b", nil, children)
+}
+
+// Strong builds strong text.
+func Strong(children ...Node) *NodeElement {
+	return newElement("strong", nil, children)
+}
+
+// I builds italic text.
+func I(children ...Node) *NodeElement {
+	return newElement("i", nil, children)
+}
+
+// Em builds emphasized text.
+func Em(children ...Node) *NodeElement {
+	return newElement("em", nil, children)
+}
+
+// U builds underlined text.
+func U(children ...Node) *NodeElement {
+	return newElement("u", nil, children)
+}
+
+// S builds struck through text.
+func S(children ...Node) *NodeElement {
+	return newElement("s", nil, children)
+}
+
+// Code builds inline code.
+func Code(code string) *NodeElement {
+	return newElement("code", nil, []Node{code})
+}
+
+// Pre builds a preformatted code block, a pre element holding a code element like MarkdownFormat builds.
+func Pre(code string) *NodeElement {
+	return newElement("pre", nil, []Node{Code(code)})
+}
+
+// Blockquote builds a quote.
+func Blockquote(children ...Node) *NodeElement {
+	return newElement("blockquote", nil, children)
+}
+
+// Aside builds a pull quote.
+func Aside(children ...Node) *NodeElement {
+	return newElement("aside", nil, children)
+}
+
+// Ul builds an unordered list of items built with Li.
+func Ul(items ...*NodeElement) *NodeElement {
+	return newElement("ul", nil, listItems(items))
+}
+
+// Ol builds an ordered list of items built with Li.
+func Ol(items ...*NodeElement) *NodeElement {
+	return newElement("ol", nil, listItems(items))
+}
+
+// Li builds a list item.
+func Li(children ...Node) *NodeElement {
+	return newElement("li", nil, children)
+}
+
+func listItems(items []*NodeElement) []Node {
+	children := make([]Node, len(items))
+	for i, item := range items {
+		children[i] = item
+	}
+	return children
+}
+
+// Br builds a line break.
+func Br() *NodeElement {
+	return newElement("br", nil, nil)
+}
+
+// Hr builds a horizontal rule.
+func Hr() *NodeElement {
+	return newElement("hr", nil, nil)
+}
+
+// Img builds an image, usually placed in a Figure.
+func Img(src string) *NodeElement {
+	return newElement("img", map[string]string{"src": src}, nil)
+}
+
+// Video builds a video, usually placed in a Figure.
+func Video(src string) *NodeElement {
+	return newElement("video", map[string]string{"src": src}, nil)
+}
+
+// Iframe builds an embedded frame, such as /embed/youtube?url=..., usually placed in a Figure.
+func Iframe(src string) *NodeElement {
+	return newElement("iframe", map[string]string{"src": src}, nil)
+}
+
+// Figure builds a figure holding media, an element built with Img, Video or Iframe, and a figcaption
+// holding caption when it is not empty.
+func Figure(media *NodeElement, caption ...Node) *NodeElement {
+	children := []Node{media}
+	if len(caption) > 0 {
+		children = append(children, newElement("figcaption", nil, caption))
+	}
+	return newElement("figure", nil, children)
+}
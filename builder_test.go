@@ -0,0 +1,101 @@
+package telegraph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestBuilder(t *testing.T) {
+	nodes := []Node{
+		H3("Report"),
+		P("Hello, ", A("https://telegra.ph/", B("World")), "!", Br(), Code("x := 1")),
+		Figure(Img("/file/cat.jpg"), "A ", Em("cat")),
+		Figure(Iframe("/embed/youtube?url=x")),
+		Ul(Li("one"), Li("two", Ol(Li("nested")))),
+		Pre("a < b"),
+		Hr(),
+	}
+
+	var sb strings.Builder
+	if err := RenderHTML(&sb, nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<h3>Report</h3><p>Hello, <a href="https://telegra.ph/"><b>World</b></a>!<br><code>x := 1</code></p>` +
+		`<figure><img src="/file/cat.jpg"><figcaption>A <em>cat</em></figcaption></figure>` +
+		`<figure><iframe src="/embed/youtube?url=x"></iframe></figure>` +
+		`<ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul><pre><code>a &lt; b</code></pre><hr>`
+	if sb.String() != expected {
+		t.Errorf("unexpected html:\ngot:  %s\nwant: %s", sb.String(), expected)
+	}
+	if err := Validate(nodes); err != nil {
+		t.Errorf("built content must be valid: %v", err)
+	}
+}
+
+func TestNewElement(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      string
+		attrs    map[string]string
+		children []Node
+		err      error
+	}{
+		{"valid", "A", map[string]string{"HREF": "/page"}, []Node{"text", B("bold")}, nil},
+		{"tag", "div", nil, nil, ErrTagNotAllowed},
+		{"attr", "a", map[string]string{"onclick": "x"}, nil, ErrAttrNotAllowed},
+		{"void children", "img", nil, []Node{"text"}, ErrInvalidDataType},
+		{"child type", "p", nil, []Node{42}, ErrInvalidDataType},
+		{"child tag", "p", nil, []Node{&NodeElement{Tag: "span"}}, ErrTagNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			element, err := NewElement(tt.tag, tt.attrs, tt.children...)
+			if errors.Cause(err) != tt.err {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && (element.Tag != "a" || element.Attrs["href"] != "/page") {
+				t.Errorf("unexpected element: %#v", element)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// Children may be element values or map nodes returned by GetPage.
+	valid := []Node{
+		P(NodeElement{Tag: "i", Children: Nodes{"italic"}}, map[string]any{"tag": "b", "children": []any{"bold"}}),
+		Ul(Li("one"), Li("two")),
+		map[string]any{"tag": "ol", "children": []any{"\n", map[string]any{"tag": "li"}}},
+	}
+	if err := Validate(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		nodes []Node
+		err   error
+	}{
+		{"child type", []Node{P(3.14)}, ErrInvalidDataType},
+		{"nil child", []Node{B(nil)}, ErrInvalidDataType},
+		{"nil element", []Node{B((*NodeElement)(nil))}, ErrInvalidDataType},
+		{"tag", []Node{P(&NodeElement{Tag: "span"})}, ErrTagNotAllowed},
+		{"attr", []Node{&NodeElement{Tag: "a", Attrs: map[string]string{"onclick": "x"}}}, ErrAttrNotAllowed},
+		{"void children", []Node{&NodeElement{Tag: "br", Children: Nodes{"x"}}}, ErrInvalidDataType},
+		{"figure media", []Node{Figure(P("text"))}, ErrTagNotAllowed},
+		{"figure text", []Node{&NodeElement{Tag: "figure", Children: Nodes{"text"}}}, ErrInvalidDataType},
+		{"list item", []Node{Ul(P("text"))}, ErrTagNotAllowed},
+		{"list text", []Node{&NodeElement{Tag: "ol", Children: Nodes{"text"}}}, ErrInvalidDataType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.nodes); errors.Cause(err) != tt.err {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
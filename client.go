@@ -24,6 +24,7 @@ func NewClient(accessToken string) *Client {
 	return &Client{
 		AccessToken: accessToken,
 		BaseURL:     apiURL,
+		UploadURL:   baseURL,
 		UserAgent:   "Telegraph/golang",
 		HTTPClient:  http.DefaultClient,
 		Logger:      log.New(os.Stderr, "Telegraph-golang ", log.LstdFlags).Printf,
@@ -34,11 +35,26 @@ func NewClient(accessToken string) *Client {
 type Client struct {
 	AccessToken string
 	BaseURL     string
-	UserAgent   string
-	HTTPClient  *http.Client
-	Debug       bool
-	Logger      logger
-	do          doFunc
+	// UploadURL is the base URL of the upload endpoint and of the uploaded files, https://telegra.ph/ if empty.
+	UploadURL  string
+	UserAgent  string
+	HTTPClient *http.Client
+	Debug      bool
+	Logger     logger
+	// Retry enables automatic retries of failed calls, see RetryPolicy. Nil disables retries.
+	Retry *RetryPolicy
+	// APILimiter throttles calls to api.telegra.ph, UploadLimiter throttles uploads to telegra.ph.
+	// Limiters are shared by copies of the Client. Nil disables throttling.
+	APILimiter    RateLimiter
+	UploadLimiter RateLimiter
+	do            doFunc
+}
+
+func (c *Client) uploadURL() string {
+	if c.UploadURL == "" {
+		return baseURL
+	}
+	return c.UploadURL
 }
 
 func (c *Client) debug(format string, v ...any) {
@@ -78,6 +94,13 @@ func (c *Client) parseRequest(r *request, opts ...RequestOption) error {
 		}
 		r.body = bytes.NewBufferString(bodyString)
 	}
+	if buf, ok := r.body.(*bytes.Buffer); ok && r.getBody == nil {
+		// Keep the encoded body around so that the request can be replayed on retry.
+		payload := buf.Bytes()
+		r.getBody = func() (io.Reader, error) {
+			return bytes.NewReader(payload), nil
+		}
+	}
 	c.debug("full url: %s, body: %s", fullURL, bodyString)
 
 	r.fullURL = fullURL
@@ -90,13 +113,49 @@ func (c *Client) callAPI(ctx context.Context, r *request, opts ...RequestOption)
 	if err != nil {
 		return []byte{}, err
 	}
+
+	attempts := c.Retry.attempts(r)
+	body := r.body
+	for attempt := 1; ; attempt++ {
+		if limiter := c.limiter(r); limiter != nil {
+			if err = limiter.Wait(ctx); err != nil {
+				return []byte{}, err
+			}
+		}
+
+		var statusCode int
+		data, statusCode, err = c.send(ctx, r, body)
+		if attempt >= attempts || (r.getBody == nil && body != nil) {
+			return data, err
+		}
+
+		delay, retry := c.Retry.shouldRetry(ctx, attempt, peekResponse(data), statusCode, err)
+		if !retry {
+			return data, err
+		}
+		c.debug("retrying %s in %s (attempt %d of %d), last error: %v", r.endpoint, delay, attempt+1, attempts, err)
+
+		if err = sleepContext(ctx, delay); err != nil {
+			return []byte{}, err
+		}
+		if r.getBody != nil {
+			if body, err = r.getBody(); err != nil {
+				return []byte{}, err
+			}
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r *request, body io.Reader) (data []byte, statusCode int, err error) {
 	c.debug("method: %#+v, fullUrl: %#+v", r.method, r.fullURL)
-	req, err := http.NewRequest(r.method, r.fullURL, r.body)
+	req, err := http.NewRequestWithContext(ctx, r.method, r.fullURL, body)
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, 0, err
 	}
-	req = req.WithContext(ctx)
 	req.Header = r.header
+	if r.contentLength > 0 {
+		req.ContentLength = r.contentLength
+	}
 	c.debug("request: %#+v", req)
 
 	f := c.do
@@ -104,12 +163,12 @@ func (c *Client) callAPI(ctx context.Context, r *request, opts ...RequestOption)
 		f = c.HTTPClient.Do
 	}
 	res, err := f(req)
-	if err != nil {
-		return []byte{}, err
+	if closer, ok := body.(io.Closer); ok {
+		// Like http.Transport, release streamed bodies whatever the outcome.
+		defer closer.Close()
 	}
-	data, err = io.ReadAll(res.Body)
 	if err != nil {
-		return []byte{}, err
+		return []byte{}, 0, err
 	}
 	defer func() {
 		cerr := res.Body.Close()
@@ -119,10 +178,14 @@ func (c *Client) callAPI(ctx context.Context, r *request, opts ...RequestOption)
 			err = cerr
 		}
 	}()
+	data, err = io.ReadAll(res.Body)
+	if err != nil {
+		return []byte{}, res.StatusCode, err
+	}
 
 	c.debug("response: %#v", res)
 	c.debug("response body: %s", string(data))
 	c.debug("response status code: %d", res.StatusCode)
 
-	return data, nil
+	return data, res.StatusCode, nil
 }
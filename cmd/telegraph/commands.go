@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/teamdbnn/go-telegraph"
+)
+
+func (a *app) account(ctx context.Context, args []string) error {
+	sub, args, err := a.subcommand("account", args, "create", "info", "edit", "revoke")
+	if err != nil {
+		return err
+	}
+
+	var o options
+	var shortName, authorName, authorURL, fields string
+	var save bool
+	fs := a.newFlagSet("account "+sub, "", &o)
+	switch sub {
+	case "create", "edit":
+		fs.StringVar(&shortName, "short-name", "", "account name, only displayed to the account owner")
+		fs.StringVar(&authorName, "author-name", "", "default author name of new pages")
+		fs.StringVar(&authorURL, "author-url", "", "default author profile link of new pages")
+	case "info":
+		fs.StringVar(&fields, "fields", "", "comma-separated `fields` to return among short_name, author_name, author_url, auth_url and page_count")
+	}
+	if sub == "create" || sub == "revoke" {
+		fs.BoolVar(&save, "save", false, "store the new access token in the profile")
+	}
+	fs.BoolVar(&o.secrets, "show-secrets", false, "print the access token and auth URL in the table output")
+	if _, err = o.parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	client, err := a.client(&o)
+	if err != nil {
+		return err
+	}
+
+	var acc *telegraph.Account
+	switch sub {
+	case "create":
+		acc, err = client.CreateAccount(ctx, shortName, &telegraph.CreateAccountParams{AuthorName: authorName, AuthorURL: authorURL})
+	case "info":
+		var option *telegraph.GetAccountInfoOption
+		if fields != "" {
+			option = &telegraph.GetAccountInfoOption{Fields: strings.Split(fields, ",")}
+		}
+		acc, err = client.GetAccountInfo(ctx, option)
+	case "edit":
+		acc, err = client.EditAccountInfo(ctx, &telegraph.EditAccountInfoParams{ShortName: shortName, AuthorName: authorName, AuthorURL: authorURL})
+	case "revoke":
+		acc, err = client.RevokeAccessToken(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if save {
+		if err = a.saveProfile(&o, acc); err != nil {
+			return err
+		}
+	}
+	return a.print(&o, acc)
+}
+
+func (a *app) page(ctx context.Context, args []string) error {
+	sub, args, err := a.subcommand("page", args, "create", "edit", "get", "list")
+	if err != nil {
+		return err
+	}
+
+	var o options
+	var title, contentPath, format string
+	var params telegraph.PageParams
+	var listParams telegraph.GetPageListParams
+	usageArgs, minArgs, maxArgs := "", 0, 0
+	if sub == "edit" || sub == "get" {
+		usageArgs, minArgs, maxArgs = "path", 1, 1
+	}
+	fs := a.newFlagSet("page "+sub, usageArgs, &o)
+	switch sub {
+	case "create", "edit":
+		fs.StringVar(&title, "title", "", "page title")
+		fs.StringVar(&contentPath, "content", "", "`file` holding the content, stdin if \"-\" or empty")
+		fs.StringVar(&format, "format", "", "content `format`: html, markdown or json, from the file extension by default")
+		fs.StringVar(&params.AuthorName, "author-name", "", "author name displayed below the title")
+		fs.StringVar(&params.AuthorURL, "author-url", "", "author profile link")
+		fs.BoolVar(&params.ReturnContent, "return-content", false, "return the content of the page")
+	case "get":
+		fs.BoolVar(&params.ReturnContent, "return-content", false, "return the content of the page")
+	case "list":
+		fs.IntVar(&listParams.Offset, "offset", 0, "number of pages to skip")
+		fs.IntVar(&listParams.Limit, "limit", 50, "number of pages to return, 0-200")
+	}
+	positional, err := o.parse(fs, args, minArgs, maxArgs)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(&o)
+	if err != nil {
+		return err
+	}
+
+	switch sub {
+	case "create", "edit":
+		content, err := a.readContent(contentPath, format)
+		if err != nil {
+			return err
+		}
+		var page *telegraph.Page
+		if sub == "create" {
+			page, err = client.CreatePage(ctx, title, content, &params)
+		} else {
+			page, err = client.EditPage(ctx, positional[0], title, content, &params)
+		}
+		if err != nil {
+			return err
+		}
+		return a.print(&o, page)
+
+	case "get":
+		page, err := client.GetPage(ctx, positional[0], &telegraph.GetPageParams{ReturnContent: params.ReturnContent})
+		if err != nil {
+			return err
+		}
+		return a.print(&o, page)
+
+	default:
+		list, err := client.GetPageList(ctx, &listParams)
+		if err != nil {
+			return err
+		}
+		return a.print(&o, list)
+	}
+}
+
+func (a *app) views(ctx context.Context, args []string) error {
+	var o options
+	var params telegraph.GetViewsParams
+	fs := a.newFlagSet("views", "path", &o)
+	fs.Int64Var(&params.Year, "year", 0, "count the views of this year, 2000-2100")
+	fs.Int64Var(&params.Month, "month", 0, "count the views of this month, 1-12, requires -year")
+	fs.Int64Var(&params.Day, "day", 0, "count the views of this day, 1-31, requires -month")
+	fs.Func("hour", "count the views of this `hour`, 0-24, requires -day", func(value string) error {
+		hour, err := strconv.ParseInt(value, 10, 64)
+		params.Hour = &hour
+		return err
+	})
+	positional, err := o.parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(&o)
+	if err != nil {
+		return err
+	}
+	views, err := client.GetViews(ctx, positional[0], &params)
+	if err != nil {
+		return err
+	}
+	return a.print(&o, views)
+}
+
+func (a *app) upload(ctx context.Context, args []string) error {
+	var o options
+	fs := a.newFlagSet("upload", "file...", &o)
+	filenames, err := o.parse(fs, args, 1, -1)
+	if err != nil {
+		return err
+	}
+
+	client, err := a.client(&o)
+	if err != nil {
+		return err
+	}
+	results, err := client.UploadFilesDetailed(ctx, filenames)
+	if err != nil {
+		return err
+	}
+	if err = a.print(&o, results); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			return errNotUploaded
+		}
+	}
+	return nil
+}
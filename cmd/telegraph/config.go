@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+)
+
+const (
+	envAccessToken = "TELEGRAPH_ACCESS_TOKEN"
+	envProfile     = "TELEGRAPH_PROFILE"
+	envConfigFile  = "TELEGRAPH_CONFIG"
+	defaultProfile = "default"
+)
+
+// options holds the flags shared by the commands.
+type options struct {
+	token   string
+	profile string
+	output  string
+	// secrets prints the access token and auth URL of accounts in the table output.
+	secrets bool
+}
+
+// newFlagSet returns the flag set of a command, with the shared flags.
+func (a *app) newFlagSet(name, args string, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: telegraph %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&o.token, "token", "", "access token, $"+envAccessToken+" or the profile token by default")
+	fs.StringVar(&o.profile, "profile", "", "profile `name` in the profile file, $"+envProfile+" or \"default\" by default")
+	fs.StringVar(&o.output, "o", "table", "output `format`: table or json")
+	return fs
+}
+
+// parse parses args, where flags may follow the positional arguments, and checks the shared flags and
+// the number of positional arguments, which are returned.
+func (o *options) parse(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		if consumed := args[:len(args)-len(rest)]; len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	if o.output != "table" && o.output != "json" {
+		fmt.Fprintf(fs.Output(), "invalid output format %q\n", o.output)
+		fs.Usage()
+		return nil, errUsage
+	}
+	if n := len(positional); n < minArgs || (maxArgs >= 0 && n > maxArgs) {
+		fs.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// client returns a client with the access token given by the flags, the environment or the profile.
+// An empty token is accepted, the calls that need one fail with telegraph.ErrEmptyAccessToken.
+func (a *app) client(o *options) (*telegraph.Client, error) {
+	token := o.token
+	if token == "" {
+		token = a.getenv(envAccessToken)
+	}
+	if token == "" {
+		profiles, err := a.readProfiles()
+		if err != nil {
+			return nil, err
+		}
+		token = profiles[a.profileName(o)].AccessToken
+	}
+	return a.newClient(token), nil
+}
+
+func (a *app) profileName(o *options) string {
+	switch {
+	case o.profile != "":
+		return o.profile
+	case a.getenv(envProfile) != "":
+		return a.getenv(envProfile)
+	default:
+		return defaultProfile
+	}
+}
+
+// profile is an entry of the profile file.
+type profile struct {
+	AccessToken string `json:"access_token"`
+	ShortName   string `json:"short_name,omitempty"`
+}
+
+// profilePath returns the path of the profile file, $TELEGRAPH_CONFIG if set.
+func (a *app) profilePath() (string, error) {
+	if path := a.getenv(envConfigFile); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "telegraph", "profiles.json"), nil
+}
+
+// readProfiles reads the profile file, which may not exist.
+func (a *app) readProfiles() (map[string]profile, error) {
+	path, err := a.profilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]profile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := map[string]profile{}
+	if err = json.Unmarshal(data, &profiles); err != nil {
+		return nil, errors.Wrapf(err, "read profiles %s", path)
+	}
+	return profiles, nil
+}
+
+// saveProfile stores acc in the profile selected by o. The file is only readable by the user.
+func (a *app) saveProfile(o *options, acc *telegraph.Account) error {
+	profiles, err := a.readProfiles()
+	if err != nil {
+		return err
+	}
+	name := a.profileName(o)
+	saved := profile{AccessToken: acc.AccessToken, ShortName: acc.ShortName}
+	if saved.ShortName == "" {
+		// revokeAccessToken does not return the short name.
+		saved.ShortName = profiles[name].ShortName
+	}
+	profiles[name] = saved
+
+	path, err := a.profilePath()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// readContent reads page content from the file at path, stdin if path is "-" or empty. format is html,
+// markdown or json, detected from the extension of path if empty.
+func (a *app) readContent(path, format string) ([]telegraph.Node, error) {
+	var src io.Reader = a.stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		src = f
+	}
+
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown":
+			format = "markdown"
+		case ".json":
+			format = "json"
+		default:
+			format = "html"
+		}
+	}
+
+	switch format {
+	case "html":
+		return telegraph.ContentFormat(src)
+	case "markdown", "md":
+		return telegraph.MarkdownFormat(src)
+	case "json":
+		var content telegraph.Nodes
+		if err := json.NewDecoder(src).Decode(&content); err != nil {
+			return nil, errors.Wrap(err, "read JSON content")
+		}
+		return content, nil
+	default:
+		return nil, errors.Errorf("unknown content format %q", format)
+	}
+}
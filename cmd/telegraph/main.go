@@ -0,0 +1,120 @@
+// Command telegraph calls the Telegraph API from the command line.
+//
+// Usage:
+//
+//	telegraph account create|info|edit|revoke [flags]
+//	telegraph page create|edit|get|list [flags] [path]
+//	telegraph views [flags] path
+//	telegraph upload [flags] file...
+//
+// The access token is read from the -token flag, the TELEGRAPH_ACCESS_TOKEN environment variable or the
+// profile file, in this order. The profile file, profiles.json in the telegraph directory of the user
+// configuration directory, maps profile names to access tokens; the profile is selected with -profile or
+// TELEGRAPH_PROFILE and defaults to "default". "account create -save" and "account revoke -save" store
+// the new token in the profile.
+//
+// Page content is read from the file given with -content, or stdin if it is "-" or missing. It is HTML,
+// Markdown or the JSON array of nodes of the API, detected from the file extension (.html, .md, .json)
+// or set with -format.
+//
+// Results are printed as a table, or as JSON with -o json.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+)
+
+const usage = `Usage:
+  telegraph account create|info|edit|revoke [flags]
+  telegraph page create|edit|get|list [flags] [path]
+  telegraph views [flags] path
+  telegraph upload [flags] file...
+
+Run "telegraph <command> [subcommand] -h" for the flags of a command.
+`
+
+// errUsage is returned for invalid command lines, after the usage has been printed.
+var errUsage = errors.New("invalid usage")
+
+// errNotUploaded is returned by the upload command when some files failed, after the results are printed.
+var errNotUploaded = errors.New("some files were not uploaded")
+
+// app holds the environment of a run, replaced in tests.
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	// newClient returns the client used for the calls.
+	newClient func(accessToken string) *telegraph.Client
+}
+
+func main() {
+	a := &app{
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		getenv:    os.Getenv,
+		newClient: telegraph.NewClient,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := a.run(ctx, os.Args[1:])
+	stop()
+
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "telegraph: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(a.stderr, usage)
+		return errUsage
+	}
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "account":
+		return a.account(ctx, args)
+	case "page":
+		return a.page(ctx, args)
+	case "views":
+		return a.views(ctx, args)
+	case "upload":
+		return a.upload(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(a.stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(a.stderr, "telegraph: unknown command %q\n\n%s", command, usage)
+		return errUsage
+	}
+}
+
+// subcommand splits args into a subcommand among names and its arguments.
+func (a *app) subcommand(command string, args []string, names ...string) (string, []string, error) {
+	if len(args) > 0 {
+		for _, name := range names {
+			if args[0] == name {
+				return name, args[1:], nil
+			}
+		}
+		fmt.Fprintf(a.stderr, "telegraph: unknown subcommand %q\n\n", command+" "+args[0])
+	}
+	fmt.Fprint(a.stderr, usage)
+	return "", nil, errUsage
+}
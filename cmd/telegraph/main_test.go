@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+	"github.com/teamdbnn/go-telegraph/telegraphtest"
+)
+
+// testApp runs commands against srv, with env as environment.
+type testApp struct {
+	*app
+	stdout *bytes.Buffer
+}
+
+func newTestApp(srv *telegraphtest.Server, env map[string]string) *testApp {
+	stdout := new(bytes.Buffer)
+	return &testApp{
+		app: &app{
+			stdin:     strings.NewReader(""),
+			stdout:    stdout,
+			stderr:    new(bytes.Buffer),
+			getenv:    func(key string) string { return env[key] },
+			newClient: srv.Client,
+		},
+		stdout: stdout,
+	}
+}
+
+// runJSON runs args with JSON output and decodes the result into v.
+func (a *testApp) runJSON(t *testing.T, v any, args ...string) {
+	t.Helper()
+	a.stdout.Reset()
+	if err := a.run(context.Background(), append(args, "-o", "json")); err != nil {
+		t.Fatalf("%s: %v", strings.Join(args, " "), err)
+	}
+	if err := json.Unmarshal(a.stdout.Bytes(), v); err != nil {
+		t.Fatalf("%s: %v", strings.Join(args, " "), err)
+	}
+}
+
+func TestAccountProfile(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	config := filepath.Join(t.TempDir(), "profiles.json")
+	a := newTestApp(srv, map[string]string{envConfigFile: config})
+
+	var created telegraph.Account
+	a.runJSON(t, &created, "account", "create", "-short-name", "Sandbox", "-author-name", "Anonymous", "-save")
+
+	data, err := os.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), created.AccessToken) {
+		t.Fatalf("profile must hold the access token:\n%s", data)
+	}
+
+	var info telegraph.Account
+	a.runJSON(t, &info, "account", "info", "-fields", "short_name,page_count")
+	if info.ShortName != "Sandbox" {
+		t.Errorf("unexpected account: %#v", info)
+	}
+
+	var edited telegraph.Account
+	a.runJSON(t, &edited, "account", "edit", "-author-name", "Someone")
+	if edited.AuthorName != "Someone" {
+		t.Errorf("unexpected account: %#v", edited)
+	}
+
+	other := newTestApp(srv, map[string]string{envConfigFile: config, envProfile: "other"})
+	err = other.run(context.Background(), []string{"account", "info"})
+	if !errors.Is(err, telegraph.ErrEmptyAccessToken) {
+		t.Errorf("unknown profile must have no token, got: %v", err)
+	}
+
+	// The table output only shows the secrets on request.
+	for _, secrets := range []bool{false, true} {
+		args := []string{"account", "info", "-fields", "short_name,auth_url"}
+		if secrets {
+			args = append(args, "-show-secrets")
+		}
+		a.stdout.Reset()
+		if err = a.run(context.Background(), args); err != nil {
+			t.Fatal(err)
+		}
+		if shown := strings.Contains(a.stdout.String(), "auth_url"); shown != secrets {
+			t.Errorf("auth_url shown %t with %v:\n%s", shown, args, a.stdout)
+		}
+	}
+
+	// revokeAccessToken does not return the short name, the profile keeps it.
+	var renewed telegraph.Account
+	a.runJSON(t, &renewed, "account", "revoke", "-save")
+	data, err = os.ReadFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), renewed.AccessToken) || !strings.Contains(string(data), `"short_name": "Sandbox"`) {
+		t.Errorf("profile must hold the new token and the short name:\n%s", data)
+	}
+
+	var revoked telegraph.Account
+	a.runJSON(t, &revoked, "account", "revoke", "-token", renewed.AccessToken)
+	err = a.run(context.Background(), []string{"account", "info", "-token", renewed.AccessToken})
+	if !telegraph.IsAccessTokenInvalid(err) {
+		t.Errorf("revoked token must be rejected, got: %v", err)
+	}
+}
+
+func TestPageCommands(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	a := newTestApp(srv, map[string]string{envAccessToken: srv.NewAccount("Sandbox")})
+
+	dir := t.TempDir()
+	markdown := filepath.Join(dir, "page.md")
+	if err := os.WriteFile(markdown, []byte("Hello, **world**!\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var page telegraph.Page
+	a.runJSON(t, &page, "page", "create", "-title", "Hello", "-content", markdown, "-return-content")
+	if page.Title != "Hello" || len(page.Content) != 1 {
+		t.Fatalf("unexpected page: %#v", page)
+	}
+
+	a.stdin = strings.NewReader(`[{"tag":"p","children":["Edited"]}]`)
+	var edited telegraph.Page
+	a.runJSON(t, &edited, "page", "edit", "-title", "Edited", "-format", "json", page.Path)
+	if edited.Path != page.Path || edited.Title != "Edited" || edited.Description != "Edited" {
+		t.Errorf("unexpected page: %#v", edited)
+	}
+
+	var list telegraph.PageList
+	a.runJSON(t, &list, "page", "list")
+	if list.TotalCount != 1 || list.Pages[0].Path != page.Path {
+		t.Errorf("unexpected page list: %#v", list)
+	}
+
+	a.stdout.Reset()
+	if err := a.run(context.Background(), []string{"page", "get", "-return-content", page.Path}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"title        Edited\n", "content      <p>Edited</p>\n"} {
+		if !strings.Contains(a.stdout.String(), want) {
+			t.Errorf("table output must contain %q, got:\n%s", want, a.stdout)
+		}
+	}
+
+	srv.AddViews(page.Path, srv.Now(), 3)
+	var views telegraph.PageViews
+	a.runJSON(t, &views, "views", page.Path)
+	if views.Views != 3 {
+		t.Errorf("expected 3 views, got %d", views.Views)
+	}
+
+	// Hour 0 is sent when it is given.
+	srv.AddViews(page.Path, time.Date(2024, 1, 2, 0, 30, 0, 0, time.UTC), 2)
+	srv.AddViews(page.Path, time.Date(2024, 1, 2, 5, 0, 0, 0, time.UTC), 1)
+	a.runJSON(t, &views, "views", "-year", "2024", "-month", "1", "-day", "2", "-hour", "0", page.Path)
+	if views.Views != 2 {
+		t.Errorf("expected the 2 views of hour 0, got %d", views.Views)
+	}
+}
+
+func TestUploadCommand(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	a := newTestApp(srv, nil)
+
+	dir := t.TempDir()
+	image := filepath.Join(dir, "image.png")
+	if err := os.WriteFile(image, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	text := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(text, []byte("notes"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := a.run(context.Background(), []string{"upload", "-o", "json", image, text})
+	if !errors.Is(err, errNotUploaded) {
+		t.Errorf("failed file must be reported, got: %v", err)
+	}
+
+	var results []uploadOutput
+	if err = json.Unmarshal(a.stdout.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Src == "" || results[1].Error == "" {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+	if _, ok := srv.File(results[0].Src); !ok {
+		t.Errorf("file %s must be uploaded", results[0].Src)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	a := newTestApp(srv, nil)
+
+	for _, args := range [][]string{
+		nil,
+		{"pages"},
+		{"account", "delete"},
+		{"page", "get"},
+		{"views", "-o", "yaml", "Page-12-15"},
+	} {
+		if err := a.run(context.Background(), args); !errors.Is(err, errUsage) {
+			t.Errorf("%q: expected a usage error, got: %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+)
+
+// uploadOutput is the printed form of an upload result.
+type uploadOutput struct {
+	Filename    string `json:"filename"`
+	Src         string `json:"src,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// print writes v to stdout in the output format selected by o.
+func (a *app) print(o *options, v any) error {
+	if results, ok := v.([]telegraph.UploadResult); ok {
+		v = uploadOutputs(results)
+	}
+
+	if o.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	switch v := v.(type) {
+	case *telegraph.Account:
+		writeFields(w,
+			"short_name", v.ShortName,
+			"author_name", v.AuthorName,
+			"author_url", v.AuthorURL,
+			"page_count", countField(v.PageCount),
+		)
+		// The access token and auth URL give access to the account: they are only printed on request.
+		if o.secrets {
+			writeFields(w, "access_token", v.AccessToken, "auth_url", v.AuthURL)
+		}
+	case *telegraph.Page:
+		writeFields(w,
+			"path", v.Path,
+			"url", v.URL,
+			"title", v.Title,
+			"description", v.Description,
+			"author_name", v.AuthorName,
+			"author_url", v.AuthorURL,
+			"image_url", v.ImageURL,
+			"views", strconv.Itoa(v.Views),
+			"can_edit", strconv.FormatBool(v.CanEdit),
+		)
+		if len(v.Content) > 0 {
+			var sb strings.Builder
+			if err := telegraph.RenderHTML(&sb, v.Content); err != nil {
+				return err
+			}
+			writeFields(w, "content", sb.String())
+		}
+	case *telegraph.PageList:
+		fmt.Fprintln(w, "PATH\tTITLE\tVIEWS\tURL")
+		for _, page := range v.Pages {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", page.Path, page.Title, page.Views, page.URL)
+		}
+		fmt.Fprintf(w, "\n%d of %d pages\n", len(v.Pages), v.TotalCount)
+	case *telegraph.PageViews:
+		fmt.Fprintf(w, "views\t%d\n", v.Views)
+	case []uploadOutput:
+		fmt.Fprintln(w, "FILE\tSIZE\tTYPE\tURL\tERROR")
+		for _, result := range v {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", result.Filename, result.Size, result.ContentType, result.URL, result.Error)
+		}
+	default:
+		return errors.Errorf("cannot print %T as a table", v)
+	}
+	return w.Flush()
+}
+
+// writeFields writes name and value pairs, skipping the empty values.
+func writeFields(w io.Writer, pairs ...string) {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			fmt.Fprintf(w, "%s\t%s\n", pairs[i], pairs[i+1])
+		}
+	}
+}
+
+// countField formats n, empty when zero as the field is not returned then.
+func countField(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func uploadOutputs(results []telegraph.UploadResult) []uploadOutput {
+	outputs := make([]uploadOutput, len(results))
+	for i, result := range results {
+		outputs[i] = uploadOutput{
+			Filename:    result.Filename,
+			Src:         result.Src,
+			URL:         result.URL,
+			Size:        result.Size,
+			ContentType: result.ContentType,
+		}
+		if result.Err != nil {
+			outputs[i].Error = result.Err.Error()
+		}
+	}
+	return outputs
+}
@@ -3,75 +3,392 @@ package telegraph
 import (
 	"bytes"
 	"io"
+	"net/url"
 	"strings"
 
+	"github.com/pkg/errors"
 	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
 )
 
+// FilterFunc reports whether a DOM node must be dropped, with all its content for elements.
+// Nodes are linked to their parent, siblings and children, see WithFilters.
 type FilterFunc func(domNode *html.Node) bool
 
+// ContentOption configures ContentFormatWithOptions.
+type ContentOption interface {
+	applyContent(o *contentOptions)
+}
+
+type contentOptions struct {
+	filters []FilterFunc
+	policy  *SanitizePolicy
+	baseURL *url.URL
+	schemes map[string]bool
+}
+
+type contentOptionFunc func(o *contentOptions)
+
+func (f contentOptionFunc) applyContent(o *contentOptions) {
+	f(o)
+}
+
+// WithFilters drops the DOM nodes for which one of filters returns true. So that filters see linked
+// nodes, the input is parsed into a DOM with html.ParseFragment and the DOM is then converted: the whole
+// document is held in memory instead of being converted in a single pass over its tokens.
+func WithFilters(filters ...FilterFunc) ContentOption {
+	return contentOptionFunc(func(o *contentOptions) {
+		o.filters = append(o.filters, filters...)
+	})
+}
+
+// htmlVoidTags lists the HTML elements that never have an end tag.
+var htmlVoidTags = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// blockTags lists the Telegraph elements that cannot be nested in a paragraph.
+var blockTags = map[string]bool{
+	"aside": true, "blockquote": true, "figure": true, "h3": true, "h4": true, "hr": true, "ol": true,
+	"p": true, "pre": true, "ul": true,
+}
+
+// closesParagraph lists the elements whose start tag implicitly closes an open p element.
+var closesParagraph = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "div": true,
+	"dl": true, "fieldset": true, "figcaption": true, "figure": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true,
+	"main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
 // ContentFormat transforms data to a DOM-based format to represent the content of the page.
-func ContentFormat(data any, filters ...FilterFunc) (n []Node, err error) {
-	var dst *html.Node
+// The data is read as the content of a body element and the top-level nodes are returned as a flat list.
+// Only the href and src attributes are kept, and URLs with a scheme missing from DefaultURLSchemes are
+// dropped. Elements are sanitized with DefaultSanitizePolicy: elements with a tag not accepted by
+// Telegraph are dropped and their children are kept in their place. A paragraph that ends up holding
+// blocks, such as a div converted to p, is split around them.
+//
+// Without filters, the input is converted in a single pass over the tokens of an html.Tokenizer, so large
+// documents are streamed from an io.Reader and deeply nested documents are converted in linear time.
+// Filters are applied like with WithFilters, on a DOM of the whole input.
+func ContentFormat(data any, filters ...FilterFunc) ([]Node, error) {
+	if len(filters) > 0 {
+		return ContentFormatWithOptions(data, WithFilters(filters...))
+	}
+	return ContentFormatWithOptions(data)
+}
+
+// ContentFormatWithOptions is ContentFormat configured with opts. Relative URLs are resolved against the
+// URL given with WithBaseURL, the accepted URL schemes are set with WithURLSchemes, elements are
+// sanitized with the policy given with WithSanitizePolicy and DOM nodes are dropped with WithFilters.
+func ContentFormatWithOptions(data any, opts ...ContentOption) ([]Node, error) {
+	var src io.Reader
 
-	switch src := data.(type) {
+	switch v := data.(type) {
 	case string:
-		dst, err = html.Parse(strings.NewReader(src))
+		src = strings.NewReader(v)
 	case []byte:
-		dst, err = html.Parse(bytes.NewReader(src))
+		src = bytes.NewReader(v)
 	case io.Reader:
-		dst, err = html.Parse(src)
+		src = v
 	default:
 		return nil, ErrInvalidDataType
 	}
 
+	o := &contentOptions{
+		policy:  DefaultSanitizePolicy(),
+		schemes: schemeSet(DefaultURLSchemes),
+	}
+	for _, opt := range opts {
+		opt.applyContent(o)
+	}
+
+	c := &contentConverter{contentOptions: o}
+	if len(o.filters) > 0 {
+		return c.convertDOM(src)
+	}
+	return c.convertTokens(src)
+}
+
+// convertTokens converts the tokens of src as they are read.
+func (c *contentConverter) convertTokens(src io.Reader) ([]Node, error) {
+	z := html.NewTokenizer(src)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return c.root, nil
+		case html.TextToken:
+			c.text(string(z.Text()))
+		case html.StartTagToken:
+			token := z.Token()
+			c.start(token.Data, token.Attr, false)
+		case html.SelfClosingTagToken:
+			token := z.Token()
+			c.start(token.Data, token.Attr, true)
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			c.end(string(name))
+		case html.CommentToken, html.DoctypeToken:
+		}
+	}
+}
+
+// convertDOM parses src into a DOM and converts its nodes, so that filters see linked nodes.
+func (c *contentConverter) convertDOM(src io.Reader) ([]Node, error) {
+	c.parsed = true
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	domNodes, err := html.ParseFragment(src, body)
 	if err != nil {
 		return nil, err
 	}
+	for _, domNode := range domNodes {
+		c.convertNode(domNode)
+	}
+	return c.root, nil
+}
 
-	if node := domToNode(dst.FirstChild, filters...); node != nil {
-		n = append(n, node)
+func (c *contentConverter) convertNode(domNode *html.Node) {
+	if c.filtered(domNode) {
+		return
 	}
 
-	return n, nil
+	switch domNode.Type {
+	case html.TextNode:
+		c.text(domNode.Data)
+	case html.ElementNode:
+		c.start(domNode.Data, domNode.Attr, false)
+		for child := domNode.FirstChild; child != nil; child = child.NextSibling {
+			c.convertNode(child)
+		}
+		if !htmlVoidTags[domNode.Data] {
+			c.end(domNode.Data)
+		}
+	default:
+	}
 }
 
-func domToNode(domNode *html.Node, filters ...FilterFunc) any {
-	for _, filter := range filters {
+// openElement is an element whose end tag has not been read yet.
+type openElement struct {
+	tag string
+	// element is the converted element, nil if the tag is not allowed and the element is unwrapped.
+	element *NodeElement
+	// container is the innermost element kept in the output among this element and its ancestors,
+	// nil for top-level content.
+	container *NodeElement
+	// skipped is set when the element is dropped with its content.
+	skipped bool
+}
+
+// contentConverter builds Telegraph nodes from a stream of HTML tokens.
+type contentConverter struct {
+	*contentOptions
+	root  []Node
+	stack []openElement
+	// opened counts the open elements by tag, to skip stack scans for tags that are not open.
+	opened map[string]int
+	// skipping counts the open elements dropped with their content.
+	skipping int
+	// parsed is set when converting a DOM, whose end tags were already implied by the HTML parser.
+	parsed bool
+}
+
+func (c *contentConverter) filtered(domNode *html.Node) bool {
+	for _, filter := range c.filters {
 		if filter(domNode) {
-			return nil
+			return true
 		}
 	}
-	if domNode.Type == html.TextNode {
-		return domNode.Data
-	}
+	return false
+}
 
-	if domNode.Type != html.ElementNode {
+// container returns the innermost open element kept in the output, nil at the top level.
+func (c *contentConverter) container() *NodeElement {
+	if len(c.stack) == 0 {
 		return nil
 	}
+	return c.stack[len(c.stack)-1].container
+}
+
+// appendNode adds node to the innermost open element kept in the output. Text following text, such as
+// the content of an unwrapped element, is merged into it.
+func (c *contentConverter) appendNode(node Node) {
+	nodes := &c.root
+	if container := c.container(); container != nil {
+		nodes = (*[]Node)(&container.Children)
+	}
+
+	text, isText := node.(string)
+	if isText && len(*nodes) > 0 {
+		if last, ok := (*nodes)[len(*nodes)-1].(string); ok {
+			(*nodes)[len(*nodes)-1] = last + text
+			return
+		}
+	}
+	// Whitespace between top-level blocks is not content.
+	if isText && nodes == &c.root && strings.TrimSpace(text) == "" {
+		return
+	}
+	*nodes = append(*nodes, node)
+}
+
+func (c *contentConverter) text(text string) {
+	if c.skipping > 0 || text == "" {
+		return
+	}
+	c.appendNode(text)
+}
+
+func (c *contentConverter) start(tag string, attrs []html.Attribute, selfClosing bool) {
+	if !c.parsed {
+		c.closeImplied(tag)
+	}
+
+	void := selfClosing || htmlVoidTags[tag]
+	open := openElement{tag: tag, container: c.container()}
+
+	switch {
+	case c.skipping > 0:
+		open.skipped = true
+	default:
+		sanitized, drop := c.policy.sanitizedTag(tag)
+		if drop {
+			open.skipped = true
+			break
+		}
+		if sanitized == "" {
+			break
+		}
+		open.element = &NodeElement{Tag: sanitized, Attrs: c.attrs(attrs)}
+		c.appendNode(open.element)
+		open.container = open.element
+	}
+
+	if void {
+		return
+	}
+	if open.skipped {
+		c.skipping++
+	}
+	if c.opened == nil {
+		c.opened = map[string]int{}
+	}
+	c.opened[tag]++
+	c.stack = append(c.stack, open)
+}
+
+func (c *contentConverter) end(tag string) {
+	if c.opened[tag] == 0 {
+		// End tags without a matching start tag are ignored.
+		return
+	}
+	for i := len(c.stack) - 1; i >= 0; i-- {
+		if c.stack[i].tag == tag {
+			c.pop(i)
+			return
+		}
+	}
+}
+
+// pop closes the open element at index i and every element opened after it.
+func (c *contentConverter) pop(i int) {
+	for k := len(c.stack) - 1; k >= i; k-- {
+		open := c.stack[k]
+		if open.skipped {
+			c.skipping--
+		}
+		c.opened[open.tag]--
+		c.stack = c.stack[:k]
 
-	nodeElement := new(NodeElement)
+		if open.element != nil && open.element.Tag == "p" {
+			c.splitParagraph(open.element)
+		}
+	}
+}
 
-	switch strings.ToLower(domNode.Data) {
-	case "a", "aside", "b", "blockquote", "br", "code", "em", "figcaption", "figure", "h3", "h4", "hr", "i",
-		"iframe", "img", "li", "ol", "p", "pre", "s", "strong", "u", "ul", "video":
-		nodeElement.Tag = domNode.Data
+// splitParagraph replaces a paragraph holding blocks by the blocks and paragraphs of the inline
+// content around them. The paragraph is the last node of its container, as it has just been closed.
+func (c *contentConverter) splitParagraph(p *NodeElement) {
+	hasBlock := false
+	for _, child := range p.Children {
+		if element, ok := child.(*NodeElement); ok && blockTags[element.Tag] {
+			hasBlock = true
+			break
+		}
+	}
+	if !hasBlock {
+		return
+	}
 
-		for i := range domNode.Attr {
-			switch strings.ToLower(domNode.Attr[i].Key) {
-			case "href", "src":
-				nodeElement.Attrs = map[string]string{domNode.Attr[i].Key: domNode.Attr[i].Val}
-			default:
-				continue
+	var nodes []Node
+	var inline Nodes
+	flush := func() {
+		for _, n := range inline {
+			if text, ok := n.(string); !ok || strings.TrimSpace(text) != "" {
+				nodes = append(nodes, &NodeElement{Tag: "p", Children: inline})
+				break
 			}
 		}
+		inline = nil
 	}
+	for _, child := range p.Children {
+		if element, ok := child.(*NodeElement); ok && blockTags[element.Tag] {
+			flush()
+			nodes = append(nodes, element)
+			continue
+		}
+		inline = append(inline, child)
+	}
+	flush()
 
-	for child := domNode.FirstChild; child != nil; child = child.NextSibling {
-		if node := domToNode(child, filters...); node != nil {
-			nodeElement.Children = append(nodeElement.Children, domToNode(child, filters...))
+	if container := c.container(); container != nil {
+		container.Children = append(container.Children[:len(container.Children)-1], nodes...)
+		return
+	}
+	c.root = append(c.root[:len(c.root)-1], nodes...)
+}
+
+// closeImplied closes the open elements that the start tag of tag ends without an explicit end tag,
+// following the most common rules of the HTML parsing algorithm.
+func (c *contentConverter) closeImplied(tag string) {
+	switch {
+	case closesParagraph[tag]:
+		c.closeInScope("p", "table", "td", "th", "caption", "button")
+		if isHeading(tag) {
+			if n := len(c.stack); n > 0 && isHeading(c.stack[n-1].tag) {
+				c.pop(n - 1)
+			}
 		}
+	case tag == "li":
+		c.closeInScope("li", "ul", "ol")
+	case tag == "dt" || tag == "dd":
+		c.closeInScope("dt", "dl")
+		c.closeInScope("dd", "dl")
+	}
+}
+
+// closeInScope closes the innermost open tag element unless one of the boundaries is opened after it.
+func (c *contentConverter) closeInScope(tag string, boundaries ...string) {
+	if c.opened[tag] == 0 {
+		return
 	}
+	for i := len(c.stack) - 1; i >= 0; i-- {
+		open := c.stack[i].tag
+		if open == tag {
+			c.pop(i)
+			return
+		}
+		for _, boundary := range boundaries {
+			if open == boundary {
+				return
+			}
+		}
+	}
+}
 
-	return nodeElement
+func isHeading(tag string) bool {
+	return len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6'
 }
@@ -1,7 +1,13 @@
 package telegraph
 
 import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+
+	"golang.org/x/net/html"
 )
 
 func TestContentFormat(t *testing.T) {
@@ -32,4 +38,213 @@ func TestContentFormat(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("fragment", func(t *testing.T) {
+		nodes, err := ContentFormat("<h3>Title</h3>\n<div><p>Hello, <span>World</span>!</p></div>")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "h3", Children: Nodes{"Title"}},
+			&NodeElement{Tag: "p", Children: Nodes{"Hello, World!"}},
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+
+	t.Run("implied end tags", func(t *testing.T) {
+		nodes, err := ContentFormat("<p>one<p>two<ul><li>a<li>b</ul><h3>x<h4>y</h4></b>tail")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "p", Children: Nodes{"one"}},
+			&NodeElement{Tag: "p", Children: Nodes{"two"}},
+			&NodeElement{Tag: "ul", Children: Nodes{
+				&NodeElement{Tag: "li", Children: Nodes{"a"}},
+				&NodeElement{Tag: "li", Children: Nodes{"b"}},
+			}},
+			&NodeElement{Tag: "h3", Children: Nodes{"x"}},
+			&NodeElement{Tag: "h4", Children: Nodes{"y"}},
+			"tail",
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		dropCode := func(domNode *html.Node) bool {
+			return domNode.Type == html.ElementNode && domNode.Data == "code"
+		}
+		nodes, err := ContentFormat(strings.NewReader("<p>keep <code>drop <b>me</b></code>this<br/>!</p>"), dropCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "p", Children: Nodes{"keep this", &NodeElement{Tag: "br"}, "!"}},
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+}
+
+func TestContentFormatLinkedFilter(t *testing.T) {
+	// Filters see the DOM: drop the text of the list items of ordered lists only.
+	inOrderedList := func(domNode *html.Node) bool {
+		for parent := domNode.Parent; parent != nil; parent = parent.Parent {
+			if parent.Data == "ol" {
+				return domNode.Type == html.TextNode
+			}
+		}
+		return false
+	}
+	nodes, err := ContentFormat("<ul><li>kept</li></ul><ol><li><b>dropped</b></li></ol>", inOrderedList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Node{
+		&NodeElement{Tag: "ul", Children: Nodes{&NodeElement{Tag: "li", Children: Nodes{"kept"}}}},
+		&NodeElement{Tag: "ol", Children: Nodes{&NodeElement{Tag: "li", Children: Nodes{&NodeElement{Tag: "b"}}}}},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("unexpected nodes: %#v", nodes)
+	}
+
+	// The DOM already holds the end tags implied by the HTML parser: it is converted like the tokens.
+	src := "<ul><li>a<li>b</ul><p>text<h3>title</h3><div>x<p>y</div>"
+	streamed, err := ContentFormat(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ContentFormatWithOptions(src, WithFilters(func(*html.Node) bool { return false }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, streamed) {
+		t.Errorf("unexpected nodes:\ngot:  %#v\nwant: %#v", parsed, streamed)
+	}
+}
+
+func TestContentFormatSanitize(t *testing.T) {
+	src := `<h1>Title</h1><h6>Small</h6><div>intro <p>para</p> outro</div>` +
+		`<p><span style="x">a</span><font>b</font><del>c</del></p><script>alert(1)</script><table><tr><td>cell</td></tr></table>`
+
+	t.Run("default", func(t *testing.T) {
+		nodes, err := ContentFormat(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "h3", Children: Nodes{"Title"}},
+			&NodeElement{Tag: "h4", Children: Nodes{"Small"}},
+			&NodeElement{Tag: "p", Children: Nodes{"intro "}},
+			&NodeElement{Tag: "p", Children: Nodes{"para"}},
+			&NodeElement{Tag: "p", Children: Nodes{" outro"}},
+			&NodeElement{Tag: "p", Children: Nodes{"ab", &NodeElement{Tag: "s", Children: Nodes{"c"}}}},
+			"cell",
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		policy := DefaultSanitizePolicy()
+		policy.Rules["h1"] = DropTag
+		policy.Rules["td"] = RenameTag("blockquote")
+		policy.Rules["del"] = UnwrapTag
+		policy.Default = DropTag
+
+		nodes, err := ContentFormatWithOptions(src, WithSanitizePolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "h4", Children: Nodes{"Small"}},
+			&NodeElement{Tag: "p", Children: Nodes{"intro "}},
+			&NodeElement{Tag: "p", Children: Nodes{"para"}},
+			&NodeElement{Tag: "p", Children: Nodes{" outro"}},
+			&NodeElement{Tag: "p", Children: Nodes{"abc"}},
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+}
+
+func TestContentFormatAttrs(t *testing.T) {
+	src := `<a href="/page" src="x" title="t">rel</a><img src="img.png" alt="a">` +
+		`<a href="java&#9;script:alert(1)">js</a><a href=" data:text/html,x">data</a><a href="tg://resolve?domain=x">tg</a>`
+
+	t.Run("default", func(t *testing.T) {
+		nodes, err := ContentFormat(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "a", Attrs: map[string]string{"href": "/page", "src": "x"}, Children: Nodes{"rel"}},
+			&NodeElement{Tag: "img", Attrs: map[string]string{"src": "img.png"}},
+			&NodeElement{Tag: "a", Children: Nodes{"js"}},
+			&NodeElement{Tag: "a", Children: Nodes{"data"}},
+			&NodeElement{Tag: "a", Attrs: map[string]string{"href": "tg://resolve?domain=x"}, Children: Nodes{"tg"}},
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+
+	t.Run("base url and schemes", func(t *testing.T) {
+		base, _ := url.Parse("https://example.com/blog/post")
+		nodes, err := ContentFormatWithOptions(src, WithBaseURL(base), WithURLSchemes("HTTPS", "data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []Node{
+			&NodeElement{Tag: "a", Attrs: map[string]string{
+				"href": "https://example.com/page", "src": "https://example.com/blog/x",
+			}, Children: Nodes{"rel"}},
+			&NodeElement{Tag: "img", Attrs: map[string]string{"src": "https://example.com/blog/img.png"}},
+			&NodeElement{Tag: "a", Children: Nodes{"js"}},
+			&NodeElement{Tag: "a", Attrs: map[string]string{"href": "data:text/html,x"}, Children: Nodes{"data"}},
+			&NodeElement{Tag: "a", Children: Nodes{"tg"}},
+		}
+		if !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("unexpected nodes: %#v", nodes)
+		}
+	})
+}
+
+func BenchmarkContentFormat(b *testing.B) {
+	for _, depth := range []int{100, 1000, 10000} {
+		nested := strings.Repeat("<blockquote><b>", depth) + "text" + strings.Repeat("</b></blockquote>", depth)
+		b.Run(fmt.Sprintf("nested-%d", depth), func(b *testing.B) {
+			for range b.N {
+				if _, err := ContentFormat(nested); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	for _, items := range []int{100, 1000, 10000} {
+		list := "<ul>" + strings.Repeat("<li>item <ul><li>nested</li></ul></li>", items) + "</ul>"
+		b.Run(fmt.Sprintf("list-%d", items), func(b *testing.B) {
+			for range b.N {
+				if _, err := ContentFormat(list); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
 }
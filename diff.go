@@ -0,0 +1,387 @@
+package telegraph
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EditKind is the kind of change described by an Edit.
+type EditKind uint8
+
+const (
+	// EditInsert adds a node that is missing from the old content.
+	EditInsert EditKind = iota
+	// EditDelete removes a node that is missing from the new content.
+	EditDelete
+	// EditMove moves an unchanged element to another position.
+	EditMove
+	// EditText changes a text node.
+	EditText
+	// EditAttrs changes the attributes of an element whose children are diffed separately.
+	EditAttrs
+)
+
+func (k EditKind) String() string {
+	switch k {
+	case EditInsert:
+		return "insert"
+	case EditDelete:
+		return "delete"
+	case EditMove:
+		return "move"
+	case EditText:
+		return "text"
+	case EditAttrs:
+		return "attrs"
+	default:
+		return "EditKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Edit is a single change between two contents.
+type Edit struct {
+	Kind EditKind
+
+	// Position of the node in the old content, as the indexes of the node and its ancestors among their
+	// siblings, top level first. Nil for EditInsert.
+	OldPath []int
+
+	// Position of the node in the new content. Nil for EditDelete.
+	NewPath []int
+
+	// Node in the old content. Nil for EditInsert.
+	Old Node
+
+	// Node in the new content. Nil for EditDelete.
+	New Node
+}
+
+// Diff is an edit script turning some content into another one.
+type Diff []Edit
+
+// DiffNodes returns the edit script turning the old content into the new one.
+//
+// Sibling lists are aligned by matching identical nodes, and text nodes or elements with the same tag,
+// in order, preferring identical nodes. Matched elements that differ are compared in turn, so a changed
+// word is reported as a text change deep in the tree instead of a replaced paragraph; unmatched nodes
+// are deleted or inserted. Finally, a deleted element identical to an inserted one is reported as moved.
+// Tags and attributes are compared as they are, both typed *NodeElement trees and the map-based trees
+// produced by encoding/json are accepted.
+func DiffNodes(old, updated []Node) (Diff, error) {
+	keys := &nodeKeys{ids: map[string]int{}}
+	oldKeys, err := keys.keyNodes(old)
+	if err != nil {
+		return nil, err
+	}
+	updatedKeys, err := keys.keyNodes(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &differ{}
+	d.diff(nil, nil, old, updated, oldKeys, updatedKeys)
+	d.detectMoves()
+
+	return d.edits, nil
+}
+
+type differ struct {
+	edits Diff
+	// keys holds the key of the node of each EditInsert and EditDelete, for move detection.
+	keys map[int]int
+}
+
+func (d *differ) add(edit Edit, key int) {
+	if edit.Kind == EditInsert || edit.Kind == EditDelete {
+		if d.keys == nil {
+			d.keys = map[int]int{}
+		}
+		d.keys[len(d.edits)] = key
+	}
+	d.edits = append(d.edits, edit)
+}
+
+// diff compares the sibling lists old and updated, whose keys are oldKeys and updatedKeys.
+func (d *differ) diff(oldPath, newPath []int, old, updated []Node, oldKeys, updatedKeys []keyedNode) {
+	weight := func(i, j int) int32 {
+		switch {
+		case oldKeys[i].key == updatedKeys[j].key:
+			return 2
+		case nodeShape(old[i]) == nodeShape(updated[j]):
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	i, j := 0, 0
+	flush := func(iEnd, jEnd int) {
+		for ; i < iEnd; i++ {
+			d.add(Edit{Kind: EditDelete, OldPath: childPath(oldPath, i), Old: old[i]}, oldKeys[i].key)
+		}
+		for ; j < jEnd; j++ {
+			d.add(Edit{Kind: EditInsert, NewPath: childPath(newPath, j), New: updated[j]}, updatedKeys[j].key)
+		}
+	}
+
+	for _, match := range align(len(old), len(updated), weight) {
+		oi, nj := match[0], match[1]
+		flush(oi, nj)
+		i, j = oi+1, nj+1
+		if oldKeys[oi].key == updatedKeys[nj].key {
+			continue
+		}
+
+		o, n := old[oi], updated[nj]
+		op, np := childPath(oldPath, oi), childPath(newPath, nj)
+		if _, ok := o.(string); ok {
+			d.add(Edit{Kind: EditText, OldPath: op, NewPath: np, Old: o, New: n}, 0)
+			continue
+		}
+		oe, _ := asElement(o)
+		ne, _ := asElement(n)
+		if !maps.Equal(oe.Attrs, ne.Attrs) {
+			d.add(Edit{Kind: EditAttrs, OldPath: op, NewPath: np, Old: o, New: n}, 0)
+		}
+		d.diff(op, np, oe.Children, ne.Children, oldKeys[oi].children, updatedKeys[nj].children)
+	}
+	flush(len(old), len(updated))
+}
+
+// detectMoves turns each deleted element identical to an inserted one into a move.
+func (d *differ) detectMoves() {
+	deleted := map[int][]int{}
+	for k, edit := range d.edits {
+		if _, isText := edit.Old.(string); edit.Kind == EditDelete && !isText {
+			deleted[d.keys[k]] = append(deleted[d.keys[k]], k)
+		}
+	}
+
+	moved := map[int]bool{}
+	for k, edit := range d.edits {
+		if _, isText := edit.New.(string); edit.Kind != EditInsert || isText {
+			continue
+		}
+		candidates := deleted[d.keys[k]]
+		if len(candidates) == 0 {
+			continue
+		}
+		deleted[d.keys[k]] = candidates[1:]
+
+		move := &d.edits[candidates[0]]
+		move.Kind = EditMove
+		move.NewPath = edit.NewPath
+		move.New = edit.New
+		moved[k] = true
+	}
+
+	if len(moved) > 0 {
+		edits := d.edits[:0]
+		for k, edit := range d.edits {
+			if !moved[k] {
+				edits = append(edits, edit)
+			}
+		}
+		d.edits = edits
+	}
+}
+
+// maxAlignCells bounds the size of the table used by align.
+const maxAlignCells = 1 << 22
+
+// align returns the index pairs of an increasing matching between two lists of n and m items with the
+// highest total weight, where weight(i, j) is the score of matching item i with item j, and 0 means
+// they cannot be matched. Leading and trailing items with the maximum weight of 2 are matched first; the
+// rest is compared with a dynamic programming table, unless it is too large, in which case it is left
+// unmatched.
+func align(n, m int, weight func(i, j int) int32) [][2]int {
+	var matches [][2]int
+	prefix := 0
+	for prefix < n && prefix < m && weight(prefix, prefix) == 2 {
+		matches = append(matches, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && suffix < m-prefix && weight(n-1-suffix, m-1-suffix) == 2 {
+		suffix++
+	}
+
+	rows, cols := n-prefix-suffix, m-prefix-suffix
+	if rows > 0 && cols > 0 && (rows+1)*(cols+1) <= maxAlignCells {
+		// table[i][j] is the best score for the items after prefix+i and prefix+j.
+		table := make([]int32, (rows+1)*(cols+1))
+		at := func(i, j int) *int32 { return &table[i*(cols+1)+j] }
+		for i := rows - 1; i >= 0; i-- {
+			for j := cols - 1; j >= 0; j-- {
+				best := max(*at(i+1, j), *at(i, j+1))
+				if w := weight(prefix+i, prefix+j); w > 0 {
+					best = max(best, *at(i+1, j+1)+w)
+				}
+				*at(i, j) = best
+			}
+		}
+		for i, j := 0, 0; i < rows && j < cols; {
+			switch w := weight(prefix+i, prefix+j); {
+			case w > 0 && *at(i, j) == *at(i+1, j+1)+w:
+				matches = append(matches, [2]int{prefix + i, prefix + j})
+				i++
+				j++
+			case *at(i, j) == *at(i+1, j):
+				i++
+			default:
+				j++
+			}
+		}
+	}
+
+	for k := suffix; k > 0; k-- {
+		matches = append(matches, [2]int{n - k, m - k})
+	}
+	return matches
+}
+
+func childPath(path []int, i int) []int {
+	return append(slices.Clip(path), i)
+}
+
+// nodeShape identifies the nodes that can be compared in place of each other.
+func nodeShape(n Node) string {
+	if _, ok := n.(string); ok {
+		return "#text"
+	}
+	element, _ := asElement(n)
+	return strings.ToLower(element.Tag)
+}
+
+// keyedNode holds the key of a node and the keys of its children.
+type keyedNode struct {
+	key      int
+	children []keyedNode
+}
+
+// nodeKeys numbers nodes so that identical nodes get the same key, whatever their representation.
+// Keys are computed bottom-up: an element is identified by its tag, attributes and the keys of its
+// children, so each node is serialized once however deep the tree is.
+type nodeKeys struct {
+	ids map[string]int
+}
+
+func (k *nodeKeys) keyNodes(nodes []Node) ([]keyedNode, error) {
+	keyed := make([]keyedNode, len(nodes))
+	for i, n := range nodes {
+		var err error
+		if keyed[i], err = k.keyNode(n); err != nil {
+			return nil, err
+		}
+	}
+	return keyed, nil
+}
+
+func (k *nodeKeys) keyNode(n Node) (keyedNode, error) {
+	if text, ok := n.(string); ok {
+		return keyedNode{key: k.id(strconv.Quote(text))}, nil
+	}
+
+	element, ok := asElement(n)
+	if !ok {
+		return keyedNode{}, errors.Wrapf(ErrInvalidDataType, "node of type %T", n)
+	}
+	children, err := k.keyNodes(element.Children)
+	if err != nil {
+		return keyedNode{}, err
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('<')
+	sb.WriteString(strings.ToLower(element.Tag))
+	for _, key := range slices.Sorted(maps.Keys(element.Attrs)) {
+		sb.WriteByte(' ')
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(element.Attrs[key]))
+	}
+	sb.WriteByte('>')
+	for _, child := range children {
+		sb.WriteString(strconv.Itoa(child.key))
+		sb.WriteByte(',')
+	}
+	return keyedNode{key: k.id(sb.String()), children: children}, nil
+}
+
+// id returns the key of the node serialized as s.
+func (k *nodeKeys) id(s string) int {
+	id, ok := k.ids[s]
+	if !ok {
+		id = len(k.ids) + 1
+		k.ids[s] = id
+	}
+	return id
+}
+
+// String renders the diff in a unified diff style: each edit starts with a hunk header holding the
+// 1-based positions of the node in the old and new content, followed by the rendered HTML of the
+// removed nodes prefixed with "-", and of the added nodes prefixed with "+". Moved nodes are prefixed
+// with a space.
+func (d Diff) String() string {
+	if len(d) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- old\n+++ new\n")
+	for _, edit := range d {
+		sb.WriteString("@@")
+		if edit.OldPath != nil {
+			sb.WriteString(" -" + formatPath(edit.OldPath))
+		}
+		if edit.NewPath != nil {
+			sb.WriteString(" +" + formatPath(edit.NewPath))
+		}
+		sb.WriteString(" @@ " + edit.Kind.String() + "\n")
+
+		switch edit.Kind {
+		case EditMove:
+			writeDiffLines(&sb, ' ', edit.New)
+		case EditAttrs:
+			writeDiffLines(&sb, '-', shallowElement(edit.Old))
+			writeDiffLines(&sb, '+', shallowElement(edit.New))
+		default:
+			writeDiffLines(&sb, '-', edit.Old)
+			writeDiffLines(&sb, '+', edit.New)
+		}
+	}
+	return sb.String()
+}
+
+func formatPath(path []int) string {
+	parts := make([]string, len(path))
+	for i, index := range path {
+		parts[i] = strconv.Itoa(index + 1)
+	}
+	return strings.Join(parts, ".")
+}
+
+// shallowElement returns a copy of an element without its children.
+func shallowElement(n Node) Node {
+	element, _ := asElement(n)
+	return &NodeElement{Tag: element.Tag, Attrs: element.Attrs}
+}
+
+func writeDiffLines(sb *strings.Builder, prefix byte, n Node) {
+	if n == nil {
+		return
+	}
+
+	var html strings.Builder
+	// Nodes are checked by DiffNodes, they always render.
+	_ = RenderHTML(&html, []Node{n})
+	for _, line := range strings.Split(html.String(), "\n") {
+		sb.WriteByte(prefix)
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
@@ -0,0 +1,118 @@
+package telegraph
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type diffEdit struct {
+	kind             EditKind
+	oldPath, newPath []int
+}
+
+func diffEdits(diff Diff) []diffEdit {
+	edits := make([]diffEdit, 0, len(diff))
+	for _, e := range diff {
+		edits = append(edits, diffEdit{e.Kind, e.OldPath, e.NewPath})
+	}
+	return edits
+}
+
+func TestDiffNodes(t *testing.T) {
+	old := []Node{
+		H3("Title"),
+		P("Hello, ", A("https://telegra.ph/", "World")),
+		P("removed"),
+		Figure(Img("/file/cat.jpg"), "cat"),
+		Ul(Li("one"), Li("two")),
+	}
+	// The new content is compared in the map-based representation of fetched pages.
+	data, err := json.Marshal([]Node{
+		H3("Title"),
+		P("Hello, ", A("https://example.com/", "World")),
+		Figure(Img("/file/cat.jpg"), "a cat"),
+		Ul(Li("one"), Li("two"), Li("three")),
+		Pre("code"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var updated []Node
+	if err = json.Unmarshal(data, &updated); err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := DiffNodes(old, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []diffEdit{
+		{EditAttrs, []int{1, 1}, []int{1, 1}},
+		{EditDelete, []int{2}, nil},
+		{EditText, []int{3, 1, 0}, []int{2, 1, 0}},
+		{EditInsert, nil, []int{3, 2}},
+		{EditInsert, nil, []int{4}},
+	}
+	if edits := diffEdits(diff); !reflect.DeepEqual(edits, expected) {
+		t.Errorf("unexpected edits:\n%s", diff)
+	}
+
+	t.Run("move", func(t *testing.T) {
+		diff, err := DiffNodes([]Node{P("a"), P("b"), P("c")}, []Node{P("b"), P("c"), P("a")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []diffEdit{{EditMove, []int{0}, []int{2}}}
+		if edits := diffEdits(diff); !reflect.DeepEqual(edits, expected) {
+			t.Errorf("unexpected edits:\n%s", diff)
+		}
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		diff, err := DiffNodes(old, old)
+		if err != nil || len(diff) != 0 {
+			t.Errorf("unexpected diff %v: %v", diff, err)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		nested := func(text string) []Node {
+			n := Node(text)
+			for range 1000 {
+				n = Blockquote(n)
+			}
+			return []Node{n}
+		}
+		diff, err := DiffNodes(nested("old"), nested("new"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diff) != 1 || diff[0].Kind != EditText || len(diff[0].OldPath) != 1001 {
+			t.Errorf("unexpected diff: %v", diffEdits(diff))
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := DiffNodes([]Node{42}, nil); err == nil {
+			t.Error("invalid nodes must be rejected")
+		}
+	})
+}
+
+func TestDiffString(t *testing.T) {
+	diff, err := DiffNodes(
+		[]Node{P("one"), Pre("a\nb"), Hr()},
+		[]Node{P("two"), Hr(), Pre("a\nb")},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "--- old\n+++ new\n" +
+		"@@ -1.1 +1.1 @@ text\n-one\n+two\n" +
+		"@@ -2 +3 @@ move\n <pre><code>a\n b</code></pre>\n"
+	if s := diff.String(); s != expected {
+		t.Errorf("unexpected diff:\n%s", s)
+	}
+}
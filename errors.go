@@ -1,6 +1,11 @@
 package telegraph
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 )
 
@@ -12,4 +17,146 @@ var (
 	ErrNoInputData = errors.New("no input data")
 
 	ErrEmptyAccessToken = errors.New("empty access_token")
+
+	// ErrTagNotAllowed is returned when an element is built with a tag not accepted by Telegraph.
+	ErrTagNotAllowed = errors.New("tag not allowed")
+
+	// ErrAttrNotAllowed is returned when an element is built with an attribute not accepted by Telegraph.
+	ErrAttrNotAllowed = errors.New("attribute not allowed")
+
+	// ErrFileTooBig is returned when a file to upload is larger than MaxUploadSize.
+	ErrFileTooBig = errors.New("file too big")
+
+	// ErrFileType is returned when a file to upload is not of one of the UploadContentTypes.
+	ErrFileType = errors.New("unsupported file type")
+
+	// ErrUploadMissing is returned for a sent file missing from the response of telegra.ph/upload.
+	ErrUploadMissing = errors.New("missing from upload response")
+)
+
+// Error codes returned by the Telegraph API in the "error" field of a failed response.
+const (
+	CodeAccessTokenInvalid   = "ACCESS_TOKEN_INVALID"
+	CodePageNotFound         = "PAGE_NOT_FOUND"
+	CodePageAccessDenied     = "PAGE_ACCESS_DENIED"
+	CodeFloodWait            = "FLOOD_WAIT"
+	CodeContentRequired      = "CONTENT_REQUIRED"
+	CodeContentTooBig        = "CONTENT_TOO_BIG"
+	CodeContentFormatInvalid = "CONTENT_FORMAT_INVALID"
+	CodeTitleRequired        = "TITLE_REQUIRED"
+	CodeTitleTooLong         = "TITLE_TOO_LONG"
+	CodeShortNameRequired    = "SHORT_NAME_REQUIRED"
+	CodeShortNameTooLong     = "SHORT_NAME_TOO_LONG"
+	CodeAuthorNameTooLong    = "AUTHOR_NAME_TOO_LONG"
+	CodeAuthorURLTooLong     = "AUTHOR_URL_TOO_LONG"
+	CodePathInvalid          = "PATH_INVALID"
+)
+
+var (
+	// ErrAccessTokenInvalid matches an APIError with the ACCESS_TOKEN_INVALID code.
+	ErrAccessTokenInvalid = errors.New(CodeAccessTokenInvalid)
+
+	// ErrPageNotFound matches an APIError with the PAGE_NOT_FOUND code.
+	ErrPageNotFound = errors.New(CodePageNotFound)
+
+	// ErrPageAccessDenied matches an APIError with the PAGE_ACCESS_DENIED code.
+	ErrPageAccessDenied = errors.New(CodePageAccessDenied)
+
+	// ErrFloodWait matches an APIError with a FLOOD_WAIT_N code, whatever the delay.
+	ErrFloodWait = errors.New(CodeFloodWait)
+
+	// ErrContentTooBig matches an APIError with the CONTENT_TOO_BIG code.
+	ErrContentTooBig = errors.New(CodeContentTooBig)
+
+	// ErrContentFormatInvalid matches an APIError with the CONTENT_FORMAT_INVALID code.
+	ErrContentFormatInvalid = errors.New(CodeContentFormatInvalid)
 )
+
+var codeSentinels = map[string]error{
+	CodeAccessTokenInvalid:   ErrAccessTokenInvalid,
+	CodePageNotFound:         ErrPageNotFound,
+	CodePageAccessDenied:     ErrPageAccessDenied,
+	CodeFloodWait:            ErrFloodWait,
+	CodeContentTooBig:        ErrContentTooBig,
+	CodeContentFormatInvalid: ErrContentFormatInvalid,
+}
+
+// APIError is returned when the Telegraph API answers with "ok": false.
+type APIError struct {
+	// Endpoint is the API method that failed, e.g. "getPage".
+	Endpoint string
+
+	// Message is the raw error text returned by Telegraph.
+	Message string
+
+	// Code is the parsed error code, e.g. CodePageNotFound. For FLOOD_WAIT_N errors the numeric
+	// suffix is stripped and stored in RetryAfter. Empty if Message is not a Telegraph error code.
+	Code string
+
+	// RetryAfter is the delay requested by the server for FLOOD_WAIT_N errors.
+	RetryAfter time.Duration
+}
+
+func newAPIError(endpoint, message string) *APIError {
+	endpoint, _, _ = strings.Cut(endpoint, "/")
+	e := &APIError{
+		Endpoint: endpoint,
+		Message:  message,
+	}
+
+	if !isErrorCode(message) {
+		return e
+	}
+	e.Code = message
+
+	if rest, ok := strings.CutPrefix(message, CodeFloodWait+"_"); ok {
+		if seconds, err := strconv.Atoi(rest); err == nil {
+			e.Code = CodeFloodWait
+			e.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return e
+}
+
+// isErrorCode reports whether s looks like a Telegraph error code (upper case, digits and underscores).
+func isErrorCode(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Endpoint, e.Message)
+}
+
+// Is makes errors.Is(err, ErrPageNotFound) and friends work for API errors.
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := codeSentinels[e.Code]
+	return ok && sentinel == target
+}
+
+// IsNotFound reports whether err is a PAGE_NOT_FOUND API error.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrPageNotFound)
+}
+
+// IsAccessTokenInvalid reports whether err is an ACCESS_TOKEN_INVALID API error.
+func IsAccessTokenInvalid(err error) bool {
+	return errors.Is(err, ErrAccessTokenInvalid)
+}
+
+// IsFloodWait reports whether err is a FLOOD_WAIT_N API error and returns the delay requested by the server.
+func IsFloodWait(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Code == CodeFloodWait {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package telegraph
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestAPIError(t *testing.T) {
+	t.Run("code", func(t *testing.T) {
+		err := newAPIError("getPage/Sample-Page-12-15", "PAGE_NOT_FOUND")
+		if err.Endpoint != "getPage" {
+			t.Errorf("endpoint must be getPage, got: %s", err.Endpoint)
+		}
+		if err.Code != CodePageNotFound {
+			t.Errorf("code must be %s, got: %s", CodePageNotFound, err.Code)
+		}
+		if !IsNotFound(err) {
+			t.Error("error must match ErrPageNotFound")
+		}
+		if errors.Is(err, ErrAccessTokenInvalid) {
+			t.Error("error must not match ErrAccessTokenInvalid")
+		}
+	})
+
+	t.Run("flood wait", func(t *testing.T) {
+		err := errors.Wrap(newAPIError("createPage", "FLOOD_WAIT_7"), "publish")
+		delay, ok := IsFloodWait(err)
+		if !ok {
+			t.Fatal("error must be a flood wait")
+		}
+		if delay != 7*time.Second {
+			t.Errorf("delay must be 7s, got: %s", delay)
+		}
+		if !errors.Is(err, ErrFloodWait) {
+			t.Error("error must match ErrFloodWait")
+		}
+	})
+
+	t.Run("free text", func(t *testing.T) {
+		err := newAPIError("upload", "File type invalid")
+		if err.Code != "" {
+			t.Errorf("code must be empty, got: %s", err.Code)
+		}
+		if err.Error() != "upload: File type invalid" {
+			t.Errorf("unexpected message: %s", err.Error())
+		}
+	})
+
+	t.Run("client", func(t *testing.T) {
+		c := NewClient("token")
+		c.do = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"ok":false,"error":"ACCESS_TOKEN_INVALID"}`)),
+			}, nil
+		}
+
+		_, err := c.GetAccountInfo(context.Background(), nil)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("error must be an APIError, got: %v", err)
+		}
+		if apiErr.Endpoint != "getAccountInfo" || !IsAccessTokenInvalid(err) {
+			t.Errorf("unexpected error: %#v", apiErr)
+		}
+	})
+}
@@ -86,11 +86,12 @@ func main() {
 
 	for _, p := range pages.Pages {
 		// GetViews
+		hour := int64(3)
 		views, err0 := client.GetViews(ctx, p.Path, &telegraph.GetViewsParams{
 			Year:  2016,
 			Month: 1,
 			Day:   1,
-			Hour:  3,
+			Hour:  &hour,
 		})
 		if err0 != nil {
 			log.Fatalf("* GetViews error: %s", err0)
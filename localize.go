@@ -0,0 +1,254 @@
+package telegraph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultLocalizeConcurrency is the number of files LocalizeMedia transfers at once by default.
+const DefaultLocalizeConcurrency = 4
+
+// telegraphHosts lists the hosts serving files uploaded to Telegraph.
+var telegraphHosts = map[string]bool{
+	"telegra.ph": true,
+	"graph.org":  true,
+}
+
+// LocalizeParams configures LocalizeMedia. The zero value uses the defaults.
+type LocalizeParams struct {
+	// Concurrency is the number of files downloaded and uploaded at once, DefaultLocalizeConcurrency
+	// if not positive.
+	Concurrency int
+
+	// MaxSize is the size limit of the downloaded files, MaxUploadSize if not positive.
+	MaxSize int64
+
+	// ContentTypes lists the media types accepted for the downloaded files, detected from their
+	// content, UploadContentTypes if empty.
+	ContentTypes []string
+}
+
+// LocalizeFailure describes a source that LocalizeMedia could not localize.
+type LocalizeFailure struct {
+	// Src is the URL found in the content.
+	Src string
+	Err error
+}
+
+func (f LocalizeFailure) Error() string {
+	return fmt.Sprintf("%s: %v", f.Src, f.Err)
+}
+
+// LocalizeReport summarizes a LocalizeMedia pass.
+type LocalizeReport struct {
+	// Localized maps the external URLs to the path of the uploaded file, e.g. "/file/6a5b15e7eb4d7329ca7af.jpg".
+	Localized map[string]string
+
+	// Failures lists the sources left unchanged because their download or upload failed.
+	Failures []LocalizeFailure
+}
+
+// LocalizeMedia downloads the external images and videos of content and uploads them to Telegraph, so
+// that the content does not depend on third-party hosts. The src attribute of img and video elements
+// is external when it is an absolute http or https URL to another host than telegra.ph.
+//
+// Files are downloaded with the client's HTTP client, checked against the size and content type limits
+// and uploaded with Upload, which receives opts. A copy of content is returned with the localized
+// sources replaced by the uploaded file paths; sources that failed are left as they are and listed in
+// the report. An error is only returned when ctx is done.
+func (c *Client) LocalizeMedia(ctx context.Context, content []Node, params *LocalizeParams, opts ...RequestOption) ([]Node, *LocalizeReport, error) {
+	if params == nil {
+		params = &LocalizeParams{}
+	}
+
+	var sources []string
+	seen := map[string]bool{}
+	for element := range Elements(content) {
+		src := element.Attrs["src"]
+		if isMediaTag(element.Tag) && isExternalSrc(src) && !seen[src] {
+			seen[src] = true
+			sources = append(sources, src)
+		}
+	}
+
+	report := &LocalizeReport{Localized: map[string]string{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, params.concurrency())
+	// errs holds the error of each failed source at the index of the source, so that failures are
+	// reported in the order of the content, whatever the order the transfers ended.
+	errs := make([]error, len(sources))
+
+	for i, src := range sources {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			localized, err := c.localize(ctx, src, params, opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			report.Localized[src] = localized
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			report.Failures = append(report.Failures, LocalizeFailure{Src: sources[i], Err: err})
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, report, err
+	}
+
+	localized := Transform(content, func(n Node) (Node, Action) {
+		element, ok := asElement(n)
+		if !ok || !isMediaTag(element.Tag) {
+			return nil, ActionKeep
+		}
+		src, ok := report.Localized[element.Attrs["src"]]
+		if !ok {
+			return nil, ActionKeep
+		}
+
+		attrs := maps.Clone(element.Attrs)
+		attrs["src"] = src
+		return &NodeElement{Tag: element.Tag, Attrs: attrs, Children: element.Children}, ActionReplace
+	})
+
+	return localized, report, nil
+}
+
+func (p *LocalizeParams) concurrency() int {
+	if p.Concurrency > 0 {
+		return p.Concurrency
+	}
+	return DefaultLocalizeConcurrency
+}
+
+func (p *LocalizeParams) maxSize() int64 {
+	if p.MaxSize > 0 {
+		return p.MaxSize
+	}
+	return MaxUploadSize
+}
+
+func (p *LocalizeParams) contentTypes() []string {
+	if len(p.ContentTypes) > 0 {
+		return p.ContentTypes
+	}
+	return UploadContentTypes
+}
+
+// localize downloads src and uploads it, returning the path of the uploaded file.
+func (c *Client) localize(ctx context.Context, src string, params *LocalizeParams, opts []RequestOption) (string, error) {
+	data, contentType, err := c.download(ctx, src, params.maxSize())
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(params.contentTypes(), contentType) {
+		return "", errors.Wrapf(ErrFileType, "%s", contentType)
+	}
+
+	paths, err := c.Upload(ctx, mediaFilename(src, contentType), bytes.NewReader(data), opts...)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) == 0 {
+		return "", errors.New("no file in upload response")
+	}
+	return paths[0], nil
+}
+
+// download reads the file at src, up to maxSize bytes, and detects its media type from its content.
+func (c *Client) download(ctx context.Context, src string, maxSize int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", errors.Errorf("download failed with status %s", res.Status)
+	}
+	if res.ContentLength > maxSize {
+		return nil, "", errors.Wrapf(ErrFileTooBig, "%d bytes, limit is %d", res.ContentLength, maxSize)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, "", errors.Wrapf(ErrFileTooBig, "more than %d bytes", maxSize)
+	}
+
+	return data, sniffContentType(data), nil
+}
+
+func isMediaTag(tag string) bool {
+	tag = strings.ToLower(tag)
+	return tag == "img" || tag == "video"
+}
+
+// isExternalSrc reports whether src is an absolute http or https URL to another host than Telegraph.
+func isExternalSrc(src string) bool {
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return !telegraphHosts[strings.ToLower(u.Hostname())]
+}
+
+// mediaFilename returns the name of the file at src, with an extension matching its content type.
+func mediaFilename(src, contentType string) string {
+	name := "file"
+	if u, err := url.Parse(src); err == nil {
+		if base := path.Base(u.Path); base != "." && base != "/" {
+			name = strings.TrimSuffix(base, path.Ext(base))
+		}
+	}
+
+	_, ext, _ := strings.Cut(contentType, "/")
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+	return name + "." + ext
+}
@@ -0,0 +1,82 @@
+package telegraph
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// pngHeader is enough for http.DetectContentType to detect a PNG image.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
+func TestLocalizeMedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			_, _ = w.Write(pngHeader)
+		case "/big.png":
+			_, _ = w.Write(append(pngHeader, bytes.Repeat([]byte{0}, 64)...))
+		case "/page.html":
+			_, _ = w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient("token")
+	c.do = func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`[{"src":"/file/cat.png"}]`)),
+		}, nil
+	}
+
+	content := []Node{
+		Figure(Img(server.URL + "/cat.png")),
+		P(Img(server.URL + "/cat.png")),
+		Figure(Img(server.URL+"/big.png"), "big"),
+		Figure(Video(server.URL + "/page.html")),
+		Figure(Img(server.URL + "/missing.png")),
+		Figure(Img("https://telegra.ph/file/kept.png")),
+		Figure(Img("/file/relative.png")),
+	}
+
+	localized, report, err := c.LocalizeMedia(context.Background(), content, &LocalizeParams{Concurrency: 2, MaxSize: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := map[string]string{server.URL + "/cat.png": "/file/cat.png"}; !reflect.DeepEqual(report.Localized, expected) {
+		t.Errorf("unexpected localized sources: %v", report.Localized)
+	}
+
+	var failed []string
+	for _, failure := range report.Failures {
+		failed = append(failed, strings.TrimPrefix(failure.Src, server.URL))
+	}
+	if expected := []string{"/big.png", "/page.html", "/missing.png"}; !reflect.DeepEqual(failed, expected) {
+		t.Fatalf("unexpected failures: %v", report.Failures)
+	}
+	if !errors.Is(report.Failures[0].Err, ErrFileTooBig) || !errors.Is(report.Failures[1].Err, ErrFileType) {
+		t.Errorf("unexpected failure errors: %v", report.Failures)
+	}
+
+	expected := append([]Node{
+		Figure(Img("/file/cat.png")),
+		P(Img("/file/cat.png")),
+	}, content[2:]...)
+	if !reflect.DeepEqual(localized, expected) {
+		t.Errorf("unexpected content: %#v", localized)
+	}
+	if src := content[0].(*NodeElement).Children[0].(*NodeElement).Attrs["src"]; src != server.URL+"/cat.png" {
+		t.Errorf("input modified: %s", src)
+	}
+}
@@ -0,0 +1,893 @@
+package telegraph
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	mdATXHeading    = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextH1      = regexp.MustCompile(`^ {0,3}=+[ \t]*$`)
+	mdSetextH2      = regexp.MustCompile(`^ {0,3}-+[ \t]*$`)
+	mdThematicBreak = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	mdFenceOpen     = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+	mdBulletItem    = regexp.MustCompile(`^( {0,3})([-+*])( +|$)(.*)$`)
+	mdOrderedItem   = regexp.MustCompile(`^( {0,3})(\d{1,9})([.)])( +|$)(.*)$`)
+	mdHTMLBlockOpen = regexp.MustCompile(`^ {0,3}(?:<!--|</?(?i:aside|article|blockquote|details|div|figure|h[1-6]|hr|iframe|ol|p|pre|section|table|ul|video)(?:[\s/>]|$))`)
+	mdAutolink      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^<>\s]*)>`)
+	mdEmailAutolink = regexp.MustCompile(`^<([A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)*)>`)
+	mdInlineHTMLTag = regexp.MustCompile(`^<(?i:(b|code|em|i|s|strong|u))>`)
+	mdInlineHTMLBr  = regexp.MustCompile(`^<(?i:br)\s*/?>`)
+	mdEntity        = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// MarkdownFormat transforms Markdown data to a DOM-based format to represent the content of the page.
+// Each construct is mapped to a Telegraph tag: headings to h3 (levels 1-2) and h4 (levels 3-6), emphasis
+// to em and strong, strikethrough to s, code spans to code, code blocks to pre, block quotes to blockquote,
+// lists to ul/ol/li, links to a, thematic breaks to hr and an image standing alone in a paragraph to a
+// figure with img (video for .mp4 files) and a figcaption holding the alt text. Raw HTML blocks are passed
+// through ContentFormat.
+func MarkdownFormat(data any) ([]Node, error) {
+	var src string
+
+	switch v := data.(type) {
+	case string:
+		src = v
+	case []byte:
+		src = string(v)
+	case io.Reader:
+		b, err := io.ReadAll(v)
+		if err != nil {
+			return nil, err
+		}
+		src = string(b)
+	default:
+		return nil, ErrInvalidDataType
+	}
+
+	return parseMarkdownBlocks(splitMarkdownLines(src))
+}
+
+// splitMarkdownLines splits src into lines. Tabs are kept: they only count as indentation, up to the next
+// multiple of 4 columns, where the indentation decides the structure, so that code keeps its tabs.
+func splitMarkdownLines(src string) []string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+	return strings.Split(src, "\n")
+}
+
+// indentOf returns the width in columns of the leading spaces and tabs of line.
+func indentOf(line string) int {
+	width := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return width
+		}
+	}
+	return width
+}
+
+// stripIndent removes n columns of leading indentation from line, or all of it if it is narrower. A tab
+// that is only partly removed is replaced with the spaces of its remaining columns.
+func stripIndent(line string, n int) string {
+	if n <= 0 {
+		return line
+	}
+	width := 0
+	for i := 0; i < len(line) && width < n; i++ {
+		switch line[i] {
+		case ' ':
+			width++
+		case '\t':
+			width += 4 - width%4
+		default:
+			return line[i:]
+		}
+		if width > n {
+			return strings.Repeat(" ", width-n) + line[i+1:]
+		}
+		if width == n {
+			return line[i+1:]
+		}
+	}
+	return ""
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// interruptsParagraph reports whether line starts a block that ends a running paragraph.
+func interruptsParagraph(line string) bool {
+	if indentOf(line) >= 4 {
+		return false
+	}
+	if mdFenceOpen.MatchString(line) || mdATXHeading.MatchString(line) || mdThematicBreak.MatchString(line) ||
+		mdHTMLBlockOpen.MatchString(line) || strings.HasPrefix(strings.TrimLeft(line, " "), ">") {
+		return true
+	}
+	if m := mdBulletItem.FindStringSubmatch(line); m != nil {
+		return !isBlank(m[4])
+	}
+	if m := mdOrderedItem.FindStringSubmatch(line); m != nil {
+		return m[2] == "1" && !isBlank(m[5])
+	}
+	return false
+}
+
+func parseMarkdownBlocks(lines []string) ([]Node, error) {
+	var nodes []Node
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		switch {
+		case isBlank(line):
+			i++
+		case indentOf(line) >= 4:
+			var code []string
+			for ; i < len(lines) && (isBlank(lines[i]) || indentOf(lines[i]) >= 4); i++ {
+				code = append(code, stripIndent(lines[i], 4))
+			}
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			nodes = append(nodes, markdownCodeBlock(code))
+		case mdFenceOpen.MatchString(line):
+			var node Node
+			node, i = parseMarkdownFence(lines, i)
+			nodes = append(nodes, node)
+		case mdATXHeading.MatchString(line):
+			m := mdATXHeading.FindStringSubmatch(line)
+			nodes = append(nodes, &NodeElement{Tag: markdownHeadingTag(len(m[1])), Children: parseMarkdownInline(m[2])})
+			i++
+		case mdThematicBreak.MatchString(line):
+			nodes = append(nodes, &NodeElement{Tag: "hr"})
+			i++
+		case strings.HasPrefix(strings.TrimLeft(line, " "), ">"):
+			var node Node
+			var err error
+			if node, i, err = parseMarkdownQuote(lines, i); err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case mdBulletItem.MatchString(line) || mdOrderedItem.MatchString(line):
+			var node Node
+			var err error
+			if node, i, err = parseMarkdownList(lines, i); err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, node)
+		case mdHTMLBlockOpen.MatchString(line):
+			j := i
+			for j < len(lines) && !isBlank(lines[j]) {
+				j++
+			}
+			htmlNodes, err := ContentFormat(strings.Join(lines[i:j], "\n"))
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, htmlNodes...)
+			i = j
+		default:
+			var node Node
+			node, i = parseMarkdownParagraph(lines, i)
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// markdownHeadingTag maps a Markdown heading level to the closest Telegraph heading.
+func markdownHeadingTag(level int) string {
+	if level <= 2 {
+		return "h3"
+	}
+	return "h4"
+}
+
+func parseMarkdownFence(lines []string, i int) (Node, int) {
+	m := mdFenceOpen.FindStringSubmatch(lines[i])
+	indent, fence := len(m[1]), m[2]
+	closing := regexp.MustCompile(`^ {0,3}` + regexp.QuoteMeta(fence[:1]) + `{` + strconv.Itoa(len(fence)) + `,}[ \t]*$`)
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		if closing.MatchString(lines[i]) {
+			i++
+			break
+		}
+		line := lines[i]
+		code = append(code, stripIndent(line, min(indent, indentOf(line))))
+	}
+
+	return markdownCodeBlock(code), i
+}
+
+// markdownCodeBlock returns the pre > code element of a code block made of lines.
+func markdownCodeBlock(lines []string) *NodeElement {
+	code := &NodeElement{Tag: "code", Children: Nodes{strings.Join(lines, "\n")}}
+	return &NodeElement{Tag: "pre", Children: Nodes{code}}
+}
+
+func parseMarkdownQuote(lines []string, i int) (Node, int, error) {
+	var inner []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		if indentOf(line) < 4 && strings.HasPrefix(trimmed, ">") {
+			inner = append(inner, stripIndent(trimmed[1:], 1))
+			continue
+		}
+		// Lazy continuation of a paragraph inside the quote.
+		if !isBlank(line) && len(inner) > 0 && !isBlank(inner[len(inner)-1]) && !interruptsParagraph(line) {
+			inner = append(inner, line)
+			continue
+		}
+		break
+	}
+
+	children, err := parseMarkdownBlocks(inner)
+	if err != nil {
+		return nil, i, err
+	}
+
+	quote := &NodeElement{Tag: "blockquote", Children: children}
+	// A quote holding a single paragraph is rendered by Telegraph with its text directly inside.
+	if len(children) == 1 {
+		if p, ok := children[0].(*NodeElement); ok && p.Tag == "p" {
+			quote.Children = p.Children
+		}
+	}
+
+	return quote, i, nil
+}
+
+type markdownListMarker struct {
+	ordered bool
+	// delim is the bullet character or the delimiter following the number.
+	delim byte
+	// contentIndent is the column where the item content starts.
+	contentIndent int
+	content       string
+}
+
+func parseMarkdownListMarker(line string) (markdownListMarker, bool) {
+	var indent, marker, spaces, content string
+	marker2 := ""
+	if m := mdBulletItem.FindStringSubmatch(line); m != nil {
+		indent, marker, spaces, content = m[1], m[2], m[3], m[4]
+	} else if m = mdOrderedItem.FindStringSubmatch(line); m != nil {
+		indent, marker, marker2, spaces, content = m[1], m[2], m[3], m[4], m[5]
+	} else {
+		return markdownListMarker{}, false
+	}
+
+	lm := markdownListMarker{
+		ordered: marker2 != "",
+		delim:   marker[0],
+		content: content,
+	}
+	if lm.ordered {
+		lm.delim = marker2[0]
+	}
+
+	markerEnd := len(indent) + len(marker) + len(marker2)
+	if len(spaces) == 0 || len(spaces) > 4 || isBlank(content) {
+		// The content starts one space after the marker, extra spaces belong to it (e.g. indented code).
+		lm.contentIndent = markerEnd + 1
+		lm.content = strings.Repeat(" ", max(len(spaces)-1, 0)) + content
+	} else {
+		lm.contentIndent = markerEnd + len(spaces)
+	}
+
+	return lm, true
+}
+
+func parseMarkdownList(lines []string, i int) (Node, int, error) {
+	first, _ := parseMarkdownListMarker(lines[i])
+	list := &NodeElement{Tag: "ul"}
+	if first.ordered {
+		list.Tag = "ol"
+	}
+
+	for i < len(lines) {
+		marker, ok := parseMarkdownListMarker(lines[i])
+		if !ok || marker.ordered != first.ordered || marker.delim != first.delim || mdThematicBreak.MatchString(lines[i]) {
+			break
+		}
+
+		item := []string{marker.content}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if isBlank(line) {
+				item = append(item, "")
+				continue
+			}
+			if indentOf(line) >= marker.contentIndent {
+				item = append(item, stripIndent(line, marker.contentIndent))
+				continue
+			}
+			// Lazy continuation of the last paragraph of the item.
+			if !isBlank(item[len(item)-1]) && !interruptsParagraph(line) && !mdBulletItem.MatchString(line) && !mdOrderedItem.MatchString(line) {
+				item = append(item, strings.TrimLeft(line, " \t"))
+				continue
+			}
+			break
+		}
+
+		blocks, err := parseMarkdownBlocks(item)
+		if err != nil {
+			return nil, i, err
+		}
+		list.Children = append(list.Children, &NodeElement{Tag: "li", Children: flattenListItem(blocks)})
+	}
+
+	return list, i, nil
+}
+
+// flattenListItem inlines the paragraphs of a list item, Telegraph does not render paragraphs inside li.
+func flattenListItem(blocks []Node) Nodes {
+	var children Nodes
+	paragraph := false
+	for _, block := range blocks {
+		p, ok := block.(*NodeElement)
+		if !ok || p.Tag != "p" {
+			children = append(children, block)
+			paragraph = false
+			continue
+		}
+		if paragraph {
+			children = append(children, &NodeElement{Tag: "br"})
+		}
+		children = append(children, p.Children...)
+		paragraph = true
+	}
+	return children
+}
+
+func parseMarkdownParagraph(lines []string, i int) (Node, int) {
+	start := i
+	var text []string
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if isBlank(line) {
+			break
+		}
+		if i > start {
+			if mdSetextH1.MatchString(line) || mdSetextH2.MatchString(line) {
+				return &NodeElement{Tag: "h3", Children: parseMarkdownInline(strings.Join(text, "\n"))}, i + 1
+			}
+			if interruptsParagraph(line) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(line, " \t"))
+	}
+
+	inline := newMarkdownInline()
+	children := inline.parse(strings.TrimRight(strings.Join(text, "\n"), " "))
+
+	// An image alone in its paragraph becomes a figure.
+	if len(children) == 1 {
+		if img, ok := children[0].(*NodeElement); ok && img.Tag == "img" {
+			return markdownFigure(img, inline.alts[img]), i
+		}
+	}
+
+	return &NodeElement{Tag: "p", Children: children}, i
+}
+
+func markdownFigure(img *NodeElement, caption string) *NodeElement {
+	figure := &NodeElement{Tag: "figure", Children: Nodes{img}}
+	if src := strings.ToLower(img.Attrs["src"]); strings.HasSuffix(strings.SplitN(src, "?", 2)[0], ".mp4") {
+		img.Tag = "video"
+	}
+	if caption != "" {
+		figure.Children = append(figure.Children, &NodeElement{Tag: "figcaption", Children: Nodes{caption}})
+	}
+	return figure
+}
+
+func parseMarkdownInline(s string) Nodes {
+	return newMarkdownInline().parse(strings.TrimSpace(s))
+}
+
+// markdownInline parses inline Markdown. It remembers the alt text of images, which has no
+// Telegraph attribute, so that paragraphs holding a single image can turn it into a caption.
+type markdownInline struct {
+	alts map[*NodeElement]string
+}
+
+func newMarkdownInline() *markdownInline {
+	return &markdownInline{alts: map[*NodeElement]string{}}
+}
+
+func (p *markdownInline) parse(s string) Nodes {
+	var out Nodes
+	var text strings.Builder
+	t := &inlineText{s: s, closers: map[emphasisRun]int{}}
+
+	flush := func() {
+		if text.Len() > 0 {
+			out = appendText(out, text.String())
+			text.Reset()
+		}
+	}
+	emit := func(n Node) {
+		flush()
+		out = append(out, n)
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch c {
+		case '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				emit(&NodeElement{Tag: "br"})
+				i += 2
+				continue
+			}
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				text.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '\n':
+			before := text.String()
+			trimmed := strings.TrimRight(before, " ")
+			text.Reset()
+			text.WriteString(trimmed)
+			if len(before)-len(trimmed) >= 2 {
+				emit(&NodeElement{Tag: "br"})
+			} else {
+				text.WriteByte(' ')
+			}
+			for i++; i < len(s) && s[i] == ' '; i++ {
+			}
+			continue
+		case '`':
+			n := runLength(s, i, '`')
+			if end := findCodeSpanEnd(s, i+n, n); end >= 0 {
+				emit(&NodeElement{Tag: "code", Children: Nodes{normalizeCodeSpan(s[i+n : end])}})
+				i = end + n
+				continue
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+		case '!':
+			if i+1 < len(s) && s[i+1] == '[' {
+				if node, next, ok := p.parseLink(t, i+1, true); ok {
+					emit(node)
+					i = next
+					continue
+				}
+			}
+		case '[':
+			if node, next, ok := p.parseLink(t, i, false); ok {
+				emit(node)
+				i = next
+				continue
+			}
+		case '<':
+			if node, next, ok := p.parseAngle(t, i); ok {
+				emit(node)
+				i = next
+				continue
+			}
+		case '&':
+			if m := mdEntity.FindString(s[i:]); m != "" {
+				text.WriteString(html.UnescapeString(m))
+				i += len(m)
+				continue
+			}
+		case '*', '_', '~':
+			n := runLength(s, i, c)
+			if tag, ok := emphasisTag(c, n); ok && canOpenEmphasis(s, i, n, c) {
+				if end := findEmphasisCloser(s, i+n, c, n, t.closers); end >= 0 {
+					emit(wrapEmphasis(tag, p.parse(s[i+n:end])))
+					i = end + n
+					continue
+				}
+			}
+			text.WriteString(s[i : i+n])
+			i += n
+			continue
+		}
+
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+
+	return out
+}
+
+// parseLink parses an inline link or image starting at the opening bracket s[i].
+func (p *markdownInline) parseLink(t *inlineText, i int, image bool) (Node, int, bool) {
+	s := t.s
+	labelEnd := t.labelEnd(i)
+	if labelEnd < 0 || labelEnd+1 >= len(s) || s[labelEnd+1] != '(' {
+		return nil, 0, false
+	}
+	dest, next, ok := parseLinkDestination(s, labelEnd+2)
+	if !ok {
+		return nil, 0, false
+	}
+	label := s[i+1 : labelEnd]
+
+	if image {
+		img := &NodeElement{Tag: "img", Attrs: map[string]string{"src": dest}}
+		p.alts[img] = nodesText(p.parse(label))
+		return img, next, true
+	}
+
+	return &NodeElement{Tag: "a", Attrs: map[string]string{"href": dest}, Children: p.parse(label)}, next, true
+}
+
+// parseAngle parses autolinks and the few inline HTML tags with no Markdown equivalent.
+func (p *markdownInline) parseAngle(t *inlineText, i int) (Node, int, bool) {
+	rest := t.s[i:]
+
+	if m := mdAutolink.FindStringSubmatch(rest); m != nil {
+		return &NodeElement{Tag: "a", Attrs: map[string]string{"href": m[1]}, Children: Nodes{m[1]}}, i + len(m[0]), true
+	}
+	if m := mdEmailAutolink.FindStringSubmatch(rest); m != nil {
+		return &NodeElement{Tag: "a", Attrs: map[string]string{"href": "mailto:" + m[1]}, Children: Nodes{m[1]}}, i + len(m[0]), true
+	}
+	if m := mdInlineHTMLBr.FindString(rest); m != "" {
+		return &NodeElement{Tag: "br"}, i + len(m), true
+	}
+	if m := mdInlineHTMLTag.FindStringSubmatch(rest); m != nil {
+		tag := strings.ToLower(m[1])
+		closing := "</" + tag + ">"
+		start := i + len(m[0])
+		if end := t.closingTag(closing, start); end >= 0 {
+			return &NodeElement{Tag: tag, Children: p.parse(t.s[start:end])}, end + len(closing), true
+		}
+	}
+
+	return nil, 0, false
+}
+
+// inlineText is a string being parsed inline, with the lookup tables built lazily for it.
+// The tables keep parsing linear on input full of unmatched delimiters.
+type inlineText struct {
+	s string
+	// closers memoizes emphasis closer searches.
+	closers map[emphasisRun]int
+	// labels maps the index of each matched opening bracket to its closing bracket.
+	labels map[int]int
+	// tags caches the index of the next occurrence of each closing HTML tag, -1 if none.
+	tags map[string]int
+}
+
+// labelEnd returns the index of the bracket closing the link label opened at s[i], or -1.
+func (t *inlineText) labelEnd(i int) int {
+	if t.labels == nil {
+		t.labels = map[int]int{}
+		var open []int
+		s := t.s
+		for j := 0; j < len(s); j++ {
+			switch s[j] {
+			case '\\':
+				j++
+			case '`':
+				n := runLength(s, j, '`')
+				if end := findCodeSpanEnd(s, j+n, n); end >= 0 {
+					j = end + n - 1
+				} else {
+					j += n - 1
+				}
+			case '[':
+				open = append(open, j)
+			case ']':
+				if len(open) > 0 {
+					t.labels[open[len(open)-1]] = j
+					open = open[:len(open)-1]
+				}
+			}
+		}
+	}
+
+	if end, ok := t.labels[i]; ok {
+		return end
+	}
+	return -1
+}
+
+// closingTag returns the index of the first case-insensitive occurrence of closing at or after from, or -1.
+func (t *inlineText) closingTag(closing string, from int) int {
+	if idx, ok := t.tags[closing]; ok && (idx < 0 || idx >= from) {
+		return idx
+	}
+	if t.tags == nil {
+		t.tags = map[string]int{}
+	}
+
+	idx := -1
+	for k := from; k+len(closing) <= len(t.s); k++ {
+		next := strings.Index(t.s[k:], "</")
+		if next < 0 {
+			break
+		}
+		k += next
+		if k+len(closing) <= len(t.s) && strings.EqualFold(t.s[k:k+len(closing)], closing) {
+			idx = k
+			break
+		}
+	}
+	t.tags[closing] = idx
+	return idx
+}
+
+// parseLinkDestination parses `dest "title")` starting right after the opening parenthesis.
+func parseLinkDestination(s string, i int) (string, int, bool) {
+	i = skipSpaces(s, i)
+
+	var dest string
+	if i < len(s) && s[i] == '<' {
+		start := i + 1
+		for i = start; i < len(s) && s[i] != '>'; i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '\n' || s[i] == '<' {
+				return "", 0, false
+			}
+		}
+		if i >= len(s) {
+			return "", 0, false
+		}
+		dest = s[start:i]
+		i++
+	} else {
+		start, depth := i, 0
+	loop:
+		for ; i < len(s); i++ {
+			switch c := s[i]; {
+			case c == '\\' && i+1 < len(s):
+				i++
+			case c == '(':
+				// Like CommonMark, give up on deeply nested parentheses instead of scanning the whole input.
+				if depth++; depth > 32 {
+					return "", 0, false
+				}
+			case c == ')':
+				if depth == 0 {
+					break loop
+				}
+				depth--
+			case c == ' ' || c == '\n' || c == '\t':
+				break loop
+			}
+		}
+		dest = s[start:i]
+	}
+
+	i = skipSpaces(s, i)
+	if i < len(s) && (s[i] == '"' || s[i] == '\'' || s[i] == '(') {
+		closer := s[i]
+		if closer == '(' {
+			closer = ')'
+		}
+		end := strings.IndexByte(s[i+1:], closer)
+		if end < 0 {
+			return "", 0, false
+		}
+		i = skipSpaces(s, i+end+2)
+	}
+	if i >= len(s) || s[i] != ')' {
+		return "", 0, false
+	}
+
+	return unescapeMarkdown(dest), i + 1, true
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// unescapeMarkdown resolves backslash escapes and entities in link destinations.
+func unescapeMarkdown(s string) string {
+	if !strings.ContainsAny(s, `\&`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		sb.WriteByte(s[i])
+	}
+	return html.UnescapeString(sb.String())
+}
+
+func runLength(s string, i int, c byte) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == c {
+		n++
+	}
+	return n
+}
+
+// findCodeSpanEnd returns the index of the backtick run of exactly n characters closing a code span.
+func findCodeSpanEnd(s string, from, n int) int {
+	for j := from; j < len(s); {
+		if s[j] != '`' {
+			j++
+			continue
+		}
+		m := runLength(s, j, '`')
+		if m == n {
+			return j
+		}
+		j += m
+	}
+	return -1
+}
+
+func normalizeCodeSpan(code string) string {
+	code = strings.ReplaceAll(code, "\n", " ")
+	if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+		code = code[1 : len(code)-1]
+	}
+	return code
+}
+
+func emphasisTag(c byte, n int) (string, bool) {
+	switch {
+	case c == '~' && n == 2:
+		return "s", true
+	case c != '~' && n == 1:
+		return "em", true
+	case c != '~' && n == 2:
+		return "strong", true
+	case c != '~' && n == 3:
+		return "strong em", true
+	default:
+		return "", false
+	}
+}
+
+func wrapEmphasis(tag string, children Nodes) *NodeElement {
+	if outer, inner, ok := strings.Cut(tag, " "); ok {
+		return &NodeElement{Tag: outer, Children: Nodes{&NodeElement{Tag: inner, Children: children}}}
+	}
+	return &NodeElement{Tag: tag, Children: children}
+}
+
+// leftFlanking and rightFlanking follow the CommonMark definition of delimiter runs.
+func leftFlanking(s string, i, n int) bool {
+	if i+n >= len(s) || isSpaceByte(s[i+n]) {
+		return false
+	}
+	return !isASCIIPunct(s[i+n]) || i == 0 || isSpaceByte(s[i-1]) || isASCIIPunct(s[i-1])
+}
+
+func rightFlanking(s string, i, n int) bool {
+	if i == 0 || isSpaceByte(s[i-1]) {
+		return false
+	}
+	return !isASCIIPunct(s[i-1]) || i+n >= len(s) || isSpaceByte(s[i+n]) || isASCIIPunct(s[i+n])
+}
+
+// canOpenEmphasis reports whether the delimiter run s[i:i+n] may open an emphasis.
+func canOpenEmphasis(s string, i, n int, c byte) bool {
+	left := leftFlanking(s, i, n)
+	if c != '_' {
+		return left
+	}
+	return left && (!rightFlanking(s, i, n) || isASCIIPunct(s[i-1]))
+}
+
+// canCloseEmphasis reports whether the delimiter run s[i:i+n] may close an emphasis.
+func canCloseEmphasis(s string, i, n int, c byte) bool {
+	right := rightFlanking(s, i, n)
+	if c != '_' {
+		return right
+	}
+	return right && (!leftFlanking(s, i, n) || isASCIIPunct(s[i+n]))
+}
+
+// emphasisRun identifies a search for an emphasis closer.
+type emphasisRun struct {
+	from int
+	c    byte
+	n    int
+}
+
+// findEmphasisCloser returns the index of the delimiter run closing an emphasis of n c characters.
+// Nested emphasis opened on the way is skipped as a whole. Results are memoized in closers, which
+// keeps the search linear when many openers are never closed.
+func findEmphasisCloser(s string, from int, c byte, n int, closers map[emphasisRun]int) int {
+	key := emphasisRun{from: from, c: c, n: n}
+	if end, ok := closers[key]; ok {
+		return end
+	}
+	end := scanEmphasisCloser(s, from, c, n, closers)
+	closers[key] = end
+	return end
+}
+
+func scanEmphasisCloser(s string, from int, c byte, n int, closers map[emphasisRun]int) int {
+	for j := from; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			m := runLength(s, j, '`')
+			if end := findCodeSpanEnd(s, j+m, m); end >= 0 {
+				j = end + m
+			} else {
+				j += m
+			}
+			continue
+		}
+		if s[j] != c {
+			j++
+			continue
+		}
+
+		m := runLength(s, j, c)
+		canOpen, canClose := canOpenEmphasis(s, j, m, c), canCloseEmphasis(s, j, m, c)
+		if canClose && (m == n || (m > n && !canOpen)) {
+			return j
+		}
+		next := j + m
+		if canOpen {
+			if end := findEmphasisCloser(s, next, c, m, closers); end >= 0 {
+				next = end + m
+			}
+		}
+		// Continue through the memoized search, so that later openers reuse this result.
+		return findEmphasisCloser(s, next, c, n, closers)
+	}
+	return -1
+}
+
+func appendText(nodes Nodes, text string) Nodes {
+	if len(nodes) > 0 {
+		if last, ok := nodes[len(nodes)-1].(string); ok {
+			nodes[len(nodes)-1] = last + text
+			return nodes
+		}
+	}
+	return append(nodes, text)
+}
+
+// nodesText returns the text content of nodes.
+func nodesText(nodes []Node) string {
+	var sb strings.Builder
+	for _, n := range nodes {
+		if text, ok := n.(string); ok {
+			sb.WriteString(text)
+		} else if element, ok := asElement(n); ok {
+			sb.WriteString(nodesText(element.Children))
+		}
+	}
+	return sb.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
@@ -0,0 +1,434 @@
+package telegraph
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	mdOrderedLineStart = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	mdEntityLike       = regexp.MustCompile(`^&(?:#|[A-Za-z])`)
+)
+
+// RenderMarkdown turns nodes into CommonMark that MarkdownFormat reads back to the same content.
+// h3 and h4 become level 2 and 3 headings, figures with an image or an .mp4 video become images with
+// the caption as alt text, and constructs with no Markdown equivalent (aside, iframe, u, other figures)
+// are kept as raw HTML. Both typed *NodeElement trees and the map-based trees produced by encoding/json
+// are accepted.
+func RenderMarkdown(nodes []Node) (string, error) {
+	blocks, err := markdownBlocks(nodes)
+	if err != nil {
+		return "", err
+	}
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// markdownBlocks renders nodes as a list of Markdown blocks. Consecutive inline nodes are grouped
+// into paragraphs.
+func markdownBlocks(nodes []Node) ([]string, error) {
+	var blocks []string
+	var inline []Node
+
+	flush := func() error {
+		if len(inline) == 0 {
+			return nil
+		}
+		text, err := renderMarkdownInline(inline)
+		inline = nil
+		if err != nil {
+			return err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			blocks = append(blocks, text)
+		}
+		return nil
+	}
+
+	for _, n := range nodes {
+		if _, ok := n.(string); ok {
+			inline = append(inline, n)
+			continue
+		}
+		element, ok := asElement(n)
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidDataType, "node of type %T", n)
+		}
+		if !isMarkdownBlock(element) {
+			inline = append(inline, n)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+
+		if !IsAllowedTag(element.Tag) {
+			children, err := markdownBlocks(element.Children)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, children...)
+			continue
+		}
+
+		block, err := renderMarkdownBlock(element)
+		if err != nil {
+			return nil, err
+		}
+		if block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// isMarkdownBlock reports whether element is rendered as a block of its own.
+func isMarkdownBlock(element *NodeElement) bool {
+	switch strings.ToLower(element.Tag) {
+	case "aside", "blockquote", "figure", "h3", "h4", "hr", "iframe", "ol", "p", "pre", "ul", "video":
+		return true
+	case "":
+		// Elements with an empty tag wrap other nodes, they are blocks if they hold any.
+		for _, child := range element.Children {
+			if e, ok := asElement(child); ok && isMarkdownBlock(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func renderMarkdownBlock(element *NodeElement) (string, error) {
+	switch tag := strings.ToLower(element.Tag); tag {
+	case "p":
+		text, err := renderMarkdownInline(element.Children)
+		return strings.TrimSpace(text), err
+	case "h3", "h4":
+		text, err := renderMarkdownInline(element.Children)
+		if err != nil {
+			return "", err
+		}
+		prefix := "## "
+		if tag == "h4" {
+			prefix = "### "
+		}
+		return prefix + strings.TrimSpace(strings.ReplaceAll(text, "\\\n", " ")), nil
+	case "hr":
+		return "---", nil
+	case "pre":
+		// The code is either the text of pre or wrapped in a code element, as MarkdownFormat builds it.
+		code := strings.TrimSuffix(nodesText(element.Children), "\n")
+		fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+		return fence + "\n" + code + "\n" + fence, nil
+	case "blockquote":
+		blocks, err := markdownBlocks(element.Children)
+		if err != nil {
+			return "", err
+		}
+		return prefixLines(strings.Join(blocks, "\n\n"), "> ", ">"), nil
+	case "ul", "ol":
+		return renderMarkdownList(element)
+	case "figure":
+		if text, ok := renderMarkdownFigure(element); ok {
+			return text, nil
+		}
+	}
+
+	return renderMarkdownHTML(element)
+}
+
+// renderMarkdownFigure renders a figure as an image when it holds an image or an .mp4 video and a
+// plain text caption. Other figures are kept as raw HTML.
+func renderMarkdownFigure(figure *NodeElement) (string, bool) {
+	var media *NodeElement
+	var caption string
+
+	for _, child := range figure.Children {
+		if text, ok := child.(string); ok {
+			if strings.TrimSpace(text) != "" {
+				return "", false
+			}
+			continue
+		}
+		element, ok := asElement(child)
+		if !ok {
+			return "", false
+		}
+		switch strings.ToLower(element.Tag) {
+		case "img", "video":
+			if media != nil {
+				return "", false
+			}
+			media = element
+		case "figcaption":
+			for _, c := range element.Children {
+				if _, ok := c.(string); !ok {
+					return "", false
+				}
+			}
+			caption = nodesText(element.Children)
+		default:
+			return "", false
+		}
+	}
+
+	if media == nil {
+		return "", false
+	}
+	src := media.Attrs["src"]
+	isVideo := strings.EqualFold(media.Tag, "video")
+	if isVideo != strings.HasSuffix(strings.ToLower(strings.SplitN(src, "?", 2)[0]), ".mp4") {
+		return "", false
+	}
+
+	return "![" + escapeMarkdownText(caption, false) + "](" + markdownDestination(src) + ")", true
+}
+
+func renderMarkdownList(list *NodeElement) (string, error) {
+	ordered := strings.EqualFold(list.Tag, "ol")
+	var sb strings.Builder
+	n := 0
+
+	for _, child := range list.Children {
+		item, ok := asElement(child)
+		if !ok {
+			if text, isText := child.(string); isText && strings.TrimSpace(text) == "" {
+				continue
+			}
+			return "", errors.Wrapf(ErrInvalidDataType, "list item of type %T", child)
+		}
+
+		n++
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(n) + ". "
+		}
+
+		blocks, err := markdownBlocks(item.Children)
+		if err != nil {
+			return "", err
+		}
+		// Blocks of an item are kept tight, a blank line would make the paragraphs loose.
+		body := strings.Join(blocks, "\n")
+		indent := strings.Repeat(" ", len(marker))
+
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(strings.TrimRight(marker, " "))
+		if body != "" {
+			sb.WriteByte(' ')
+			sb.WriteString(prefixLines(body, indent, "")[len(indent):])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// renderMarkdownHTML keeps element as a raw HTML block.
+func renderMarkdownHTML(element *NodeElement) (string, error) {
+	var sb strings.Builder
+	if err := RenderHTML(&sb, []Node{element}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// prefixLines prefixes every line of text, using blankPrefix for empty lines.
+func prefixLines(text, prefix, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func longestRun(s string, c byte) int {
+	longest := 0
+	for i := 0; i < len(s); {
+		if s[i] != c {
+			i++
+			continue
+		}
+		n := runLength(s, i, c)
+		longest = max(longest, n)
+		i += n
+	}
+	return longest
+}
+
+// markdownInlineWriter renders inline nodes, tracking line starts where block markers must be escaped.
+type markdownInlineWriter struct {
+	sb        strings.Builder
+	lineStart bool
+}
+
+func renderMarkdownInline(nodes []Node) (string, error) {
+	w := &markdownInlineWriter{lineStart: true}
+	if err := w.nodes(nodes); err != nil {
+		return "", err
+	}
+	return w.sb.String(), nil
+}
+
+func (w *markdownInlineWriter) write(s string) {
+	if s != "" {
+		w.sb.WriteString(s)
+		w.lineStart = false
+	}
+}
+
+func (w *markdownInlineWriter) nodes(nodes []Node) error {
+	for _, n := range nodes {
+		if err := w.node(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *markdownInlineWriter) node(n Node) error {
+	if text, ok := n.(string); ok {
+		w.text(text)
+		return nil
+	}
+
+	element, ok := asElement(n)
+	if !ok {
+		return errors.Wrapf(ErrInvalidDataType, "node of type %T", n)
+	}
+
+	switch tag := strings.ToLower(element.Tag); tag {
+	case "b", "strong":
+		return w.wrap("**", element.Children)
+	case "i", "em":
+		return w.wrap("*", element.Children)
+	case "s":
+		return w.wrap("~~", element.Children)
+	case "u":
+		w.write("<u>")
+		if err := w.nodes(element.Children); err != nil {
+			return err
+		}
+		w.write("</u>")
+	case "code":
+		code := strings.ReplaceAll(nodesText(element.Children), "\n", " ")
+		fence := strings.Repeat("`", longestRun(code, '`')+1)
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") ||
+			(strings.HasPrefix(code, " ") && strings.HasSuffix(code, " ") && strings.TrimSpace(code) != "") {
+			code = " " + code + " "
+		}
+		w.write(fence + code + fence)
+	case "br":
+		w.sb.WriteString("\\\n")
+		w.lineStart = true
+	case "a":
+		label, err := renderMarkdownInline(element.Children)
+		if err != nil {
+			return err
+		}
+		w.write("[" + label + "](" + markdownDestination(element.Attrs["href"]) + ")")
+	case "img":
+		w.write("![](" + markdownDestination(element.Attrs["src"]) + ")")
+	default:
+		if IsAllowedTag(tag) {
+			// Block elements inside inline content have no Markdown form.
+			html, err := renderMarkdownHTML(element)
+			if err != nil {
+				return err
+			}
+			w.write(html)
+			return nil
+		}
+		return w.nodes(element.Children)
+	}
+
+	return nil
+}
+
+// wrap renders children between delimiter, moving surrounding spaces outside of the delimiters
+// where they would prevent the emphasis from opening or closing.
+func (w *markdownInlineWriter) wrap(delimiter string, children []Node) error {
+	inner, err := renderMarkdownInline(children)
+	if err != nil {
+		return err
+	}
+	trimmed := strings.TrimLeft(inner, " ")
+	leading := inner[:len(inner)-len(trimmed)]
+	inner = strings.TrimRight(trimmed, " ")
+	trailing := trimmed[len(inner):]
+
+	w.write(leading)
+	if inner != "" {
+		w.write(delimiter + inner + delimiter)
+	}
+	w.write(trailing)
+	return nil
+}
+
+// text writes escaped text. Newlines become hard breaks, as soft breaks are read back as spaces.
+func (w *markdownInlineWriter) text(text string) {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if i > 0 {
+			w.sb.WriteString("\\\n")
+			w.lineStart = true
+		}
+		if w.lineStart {
+			line = strings.TrimLeft(line, " ")
+		}
+		w.write(escapeMarkdownText(line, w.lineStart))
+	}
+}
+
+// escapeMarkdownText escapes the characters of text that would be read as Markdown. When lineStart is
+// set, characters that would start a block are escaped too.
+func escapeMarkdownText(text string, lineStart bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '\\', '*', '_', '`', '[', ']', '~', '<':
+			sb.WriteByte('\\')
+		case '&':
+			if mdEntityLike.MatchString(text[i:]) {
+				sb.WriteByte('\\')
+			}
+		case '#', '>', '-', '+', '=':
+			if lineStart && i == 0 {
+				sb.WriteByte('\\')
+			}
+		}
+		sb.WriteByte(c)
+	}
+
+	escaped := sb.String()
+	if lineStart {
+		if m := mdOrderedLineStart.FindStringSubmatchIndex(escaped); m != nil {
+			escaped = escaped[:m[4]] + "\\" + escaped[m[4]:]
+		}
+	}
+	return escaped
+}
+
+// markdownDestination formats a link destination, using the angle bracket form when needed.
+func markdownDestination(dest string) string {
+	if dest == "" || strings.ContainsAny(dest, " ()<>\\") {
+		r := strings.NewReplacer("<", `\<`, ">", `\>`, "\\", `\\`)
+		return "<" + r.Replace(dest) + ">"
+	}
+	return dest
+}
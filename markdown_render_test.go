@@ -0,0 +1,96 @@
+package telegraph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	nodes := []Node{
+		&NodeElement{Tag: "h3", Children: Nodes{"Title"}},
+		&NodeElement{Tag: "p", Children: Nodes{
+			"Some ", &NodeElement{Tag: "b", Children: Nodes{"bold "}}, "and ",
+			&NodeElement{Tag: "em", Children: Nodes{"italic"}}, " text with *stars*, ",
+			&NodeElement{Tag: "a", Attrs: map[string]string{"href": "https://telegra.ph/"}, Children: Nodes{"a link"}},
+			", ", &NodeElement{Tag: "code", Children: Nodes{"x `y`"}}, " and ",
+			&NodeElement{Tag: "u", Children: Nodes{"underline"}}, ".",
+			&NodeElement{Tag: "br"}, "1. not a list",
+		}},
+		&NodeElement{Tag: "h4", Children: Nodes{"# Subtitle"}},
+		&NodeElement{Tag: "ul", Children: Nodes{
+			&NodeElement{Tag: "li", Children: Nodes{"one", &NodeElement{Tag: "ol", Children: Nodes{
+				&NodeElement{Tag: "li", Children: Nodes{"nested"}},
+				&NodeElement{Tag: "li", Children: Nodes{&NodeElement{Tag: "s", Children: Nodes{"gone"}}}},
+			}}}},
+			&NodeElement{Tag: "li", Children: Nodes{"two"}},
+		}},
+		&NodeElement{Tag: "figure", Children: Nodes{
+			&NodeElement{Tag: "img", Attrs: map[string]string{"src": "/file/cat.jpg"}},
+			&NodeElement{Tag: "figcaption", Children: Nodes{"A [cat]"}},
+		}},
+		&NodeElement{Tag: "figure", Children: Nodes{
+			&NodeElement{Tag: "iframe", Attrs: map[string]string{"src": "/embed/youtube?url=https%3A%2F%2Fyoutu.be%2Fx"}},
+			&NodeElement{Tag: "figcaption", Children: Nodes{"Video"}},
+		}},
+		&NodeElement{Tag: "blockquote", Children: Nodes{"Quote ", &NodeElement{Tag: "i", Children: Nodes{"me"}}}},
+		&NodeElement{Tag: "aside", Children: Nodes{"Side note"}},
+		&NodeElement{Tag: "pre", Children: Nodes{&NodeElement{Tag: "code", Children: Nodes{"```\ncode\n```"}}}},
+		&NodeElement{Tag: "hr"},
+	}
+
+	markdown, err := RenderMarkdown(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "## Title\n\n" +
+		"Some **bold** and *italic* text with \\*stars\\*, [a link](https://telegra.ph/), `` x `y` `` and <u>underline</u>.\\\n1\\. not a list\n\n" +
+		"### \\# Subtitle\n\n" +
+		"- one\n  1. nested\n  2. ~~gone~~\n- two\n\n" +
+		"![A \\[cat\\]](/file/cat.jpg)\n\n" +
+		`<figure><iframe src="/embed/youtube?url=https%3A%2F%2Fyoutu.be%2Fx"></iframe><figcaption>Video</figcaption></figure>` + "\n\n" +
+		"> Quote *me*\n\n" +
+		"<aside>Side note</aside>\n\n" +
+		"````\n```\ncode\n```\n````\n\n" +
+		"---\n"
+	if markdown != expected {
+		t.Errorf("unexpected markdown:\n%s\nwant:\n%s", markdown, expected)
+	}
+
+	// Reading the Markdown back must give the same content, modulo the normalizations of the importer:
+	// b and i are read back as strong and em, and spaces are moved out of emphasis.
+	back, err := MarkdownFormat(markdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want, got strings.Builder
+	_ = RenderHTML(&want, nodes)
+	_ = RenderHTML(&got, back)
+	normalized := strings.NewReplacer("<b>bold </b>", "<strong>bold</strong> ", "<i>", "<em>", "</i>", "</em>").
+		Replace(want.String())
+	if got.String() != normalized {
+		t.Errorf("content must survive a round trip:\ngot:  %s\nwant: %s", got.String(), normalized)
+	}
+
+	// A second round trip is stable.
+	again, err := RenderMarkdown(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != markdown {
+		t.Errorf("markdown must be stable:\n%s\nwant:\n%s", again, markdown)
+	}
+	if !reflect.DeepEqual(back[len(back)-1], &NodeElement{Tag: "hr"}) {
+		t.Errorf("unexpected last node: %#v", back[len(back)-1])
+	}
+
+	// Code blocks without a code element, such as those of the HTML importer, render the same.
+	pre, err := RenderMarkdown([]Node{&NodeElement{Tag: "pre", Children: Nodes{"a := 1"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pre != "```\na := 1\n```\n" {
+		t.Errorf("unexpected code block: %q", pre)
+	}
+}
@@ -0,0 +1,81 @@
+package telegraph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		html     string
+	}{
+		{"headings", "# One\n## Two\n### Three\n###### Six", "<h3>One</h3><h3>Two</h3><h4>Three</h4><h4>Six</h4>"},
+		{"setext", "Title\n=====\n\nSub\n---", "<h3>Title</h3><h3>Sub</h3>"},
+		{"paragraphs", "first line\nsame paragraph\n\nsecond", "<p>first line same paragraph</p><p>second</p>"},
+		{"hard break", "line  \nnext\\\nlast", "<p>line<br>next<br>last</p>"},
+		{"emphasis", "*em* _em_ **strong** __strong__ ***both*** ~~gone~~", "<p><em>em</em> <em>em</em> <strong>strong</strong> <strong>strong</strong> <strong><em>both</em></strong> <s>gone</s></p>"},
+		{"nested emphasis", "**bold *it***, *a **b** c*", "<p><strong>bold <em>it</em></strong>, <em>a <strong>b</strong> c</em></p>"},
+		{"intraword underscore", "snake_case_name and 2*3*4", "<p>snake_case_name and 2<em>3</em>4</p>"},
+		{"code span", "use `a *b*` here, ``x ` y``", "<p>use <code>a *b*</code> here, <code>x ` y</code></p>"},
+		{"escapes", `\*not em\* 1 &lt; 2 &copy;`, "<p>*not em* 1 &lt; 2 ©</p>"},
+		{"links", `[Tele*graph*](https://telegra.ph/ "title") <https://example.com> <me@example.com>`, `<p><a href="https://telegra.ph/">Tele<em>graph</em></a> <a href="https://example.com">https://example.com</a> <a href="mailto:me@example.com">me@example.com</a></p>`},
+		{"angle destination", `[a](<a b\>c> "t") [b](<x<y>)`, `<p><a href="a b&gt;c">a</a> [b](&lt;x&lt;y&gt;)</p>`},
+		{"link with parens", "[wiki](https://en.wikipedia.org/wiki/Go_(language))", `<p><a href="https://en.wikipedia.org/wiki/Go_(language)">wiki</a></p>`},
+		{"inline image", "see ![icon](/file/icon.png) here", `<p>see <img src="/file/icon.png"> here</p>`},
+		{"figure", "![A cat](/file/cat.jpg)", `<figure><img src="/file/cat.jpg"><figcaption>A cat</figcaption></figure>`},
+		{"video", "![](/file/clip.mp4)", `<figure><video src="/file/clip.mp4"></video></figure>`},
+		{"fenced code", "```go\nfunc main() {\n\t<-ch\n}\n```", "<pre><code>func main() {\n\t&lt;-ch\n}</code></pre>"},
+		{"tabs in fenced code", "- item\n\n  ```\n  all:\n  \tgo build\t./...\n  ```\n\n```\n\tx\n```", "<ul><li>item<pre><code>all:\n\tgo build\t./...</code></pre></li></ul><pre><code>\tx</code></pre>"},
+		{"tabs in indented code", "\tif x {\n\t\treturn\n\t}\n  \t\tdeep", "<pre><code>if x {\n\treturn\n}\n\tdeep</code></pre>"},
+		{"tab after quote marker", ">\tquoted", "<blockquote>quoted</blockquote>"},
+		{"indented code", "    a := 1\n\n    b := 2\n\ntext", "<pre><code>a := 1\n\nb := 2</code></pre><p>text</p>"},
+		{"thematic break", "a\n\n***\n\n- - -", "<p>a</p><hr><hr>"},
+		{"blockquote", "> quoted *text*\nlazy line", "<blockquote>quoted <em>text</em> lazy line</blockquote>"},
+		{"blockquote blocks", "> ### Title\n>\n> body", "<blockquote><h4>Title</h4><p>body</p></blockquote>"},
+		{"unordered list", "- one\n- two\n  continued\n- three", "<ul><li>one</li><li>two continued</li><li>three</li></ul>"},
+		{"ordered list", "1. one\n2. two\n\n3. three", "<ol><li>one</li><li>two</li><li>three</li></ol>"},
+		{"nested list", "- a\n  - b\n    1. c\n- d", "<ul><li>a<ul><li>b<ol><li>c</li></ol></li></ul></li><li>d</li></ul>"},
+		{"list interrupts paragraph", "text\n- item", "<p>text</p><ul><li>item</li></ul>"},
+		{"inline html", "<u>under</u> and<br>break", "<p><u>under</u> and<br>break</p>"},
+		{"html block", `<figure><iframe src="/embed/youtube?url=x"></iframe></figure>`, `<figure><iframe src="/embed/youtube?url=x"></iframe></figure>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := MarkdownFormat(tt.markdown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sb strings.Builder
+			if err = RenderHTML(&sb, nodes); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != tt.html {
+				t.Errorf("unexpected html:\ngot:  %s\nwant: %s", sb.String(), tt.html)
+			}
+		})
+	}
+
+	t.Run("code round trip", func(t *testing.T) {
+		code := "all:\n\tgo build ./...\n\n\tgo vet\t./..."
+		markdown, err := RenderMarkdown([]Node{Pre(code)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := MarkdownFormat(markdown)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nodesText(nodes); got != code {
+			t.Errorf("code must be kept byte for byte, got %q", got)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := MarkdownFormat(42); err != ErrInvalidDataType {
+			t.Errorf("error must be: %v", ErrInvalidDataType)
+		}
+	})
+}
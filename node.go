@@ -0,0 +1,68 @@
+package telegraph
+
+import (
+	"strings"
+)
+
+// allowedTags lists the DOM elements accepted by Telegraph.
+var allowedTags = map[string]bool{
+	"a": true, "aside": true, "b": true, "blockquote": true, "br": true, "code": true, "em": true,
+	"figcaption": true, "figure": true, "h3": true, "h4": true, "hr": true, "i": true, "iframe": true,
+	"img": true, "li": true, "ol": true, "p": true, "pre": true, "s": true, "strong": true, "u": true,
+	"ul": true, "video": true,
+}
+
+// allowedAttrs lists the DOM attributes accepted by Telegraph.
+var allowedAttrs = map[string]bool{
+	"href": true,
+	"src":  true,
+}
+
+// voidTags lists the allowed elements that never have children.
+var voidTags = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// IsAllowedTag reports whether tag is one of the DOM elements accepted by Telegraph.
+func IsAllowedTag(tag string) bool {
+	return allowedTags[strings.ToLower(tag)]
+}
+
+// IsAllowedAttr reports whether attr is one of the DOM attributes accepted by Telegraph.
+func IsAllowedAttr(attr string) bool {
+	return allowedAttrs[strings.ToLower(attr)]
+}
+
+// asElement returns n as a *NodeElement. Besides *NodeElement it accepts NodeElement values and the
+// map[string]any representation produced by decoding content into a plain []Node. Only the top level
+// of a map is converted, its children are left as they are.
+func asElement(n Node) (*NodeElement, bool) {
+	switch v := n.(type) {
+	case *NodeElement:
+		return v, v != nil
+	case NodeElement:
+		return &v, true
+	case map[string]any:
+		element := new(NodeElement)
+		element.Tag, _ = v["tag"].(string)
+		if attrs, ok := v["attrs"].(map[string]any); ok {
+			element.Attrs = make(map[string]string, len(attrs))
+			for key, value := range attrs {
+				if s, ok := value.(string); ok {
+					element.Attrs[key] = s
+				}
+			}
+		}
+		if children, ok := v["children"].([]any); ok {
+			element.Children = make(Nodes, 0, len(children))
+			for _, child := range children {
+				element.Children = append(element.Children, child)
+			}
+		}
+		return element, true
+	default:
+		return nil, false
+	}
+}
@@ -1,17 +1,10 @@
 package telegraph
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
-	"mime/multipart"
 	"net/http"
-	"os"
-
-	"github.com/pkg/errors"
 )
 
 type PageParams struct {
@@ -26,17 +19,20 @@ type PageParams struct {
 // CreatePage Use this method to create a new Telegraph page. On success, returns a Page object.
 // https://telegra.ph/api#createPage
 func (c *Client) CreatePage(ctx context.Context, title string, content []Node, params *PageParams, opts ...RequestOption) (*Page, error) {
+	contentData, err := json.Marshal(&content)
+	if err != nil {
+		return nil, err
+	}
+	if err = params.validate("createPage", title, content, contentData); err != nil {
+		return nil, err
+	}
+
 	r := &request{
 		method:   http.MethodPost,
 		endpoint: "createPage",
 		secured:  true,
 	}
-
 	r.setFormParam("title", title)
-	contentData, err := json.Marshal(&content)
-	if err != nil {
-		return nil, err
-	}
 	r.setFormParam("content", string(contentData))
 	if params != nil {
 		if params.AuthorName != "" {
@@ -60,7 +56,7 @@ func (c *Client) CreatePage(ctx context.Context, title string, content []Node, p
 		return nil, err
 	}
 	if !page.OK {
-		return nil, errors.New(page.Error)
+		return nil, newAPIError(r.endpoint, page.Error)
 	}
 	return page.Result, nil
 }
@@ -68,16 +64,20 @@ func (c *Client) CreatePage(ctx context.Context, title string, content []Node, p
 // EditPage Use this method to edit an existing Telegraph page. On success, returns a Page object.
 // https://telegra.ph/api#editPage
 func (c *Client) EditPage(ctx context.Context, path, title string, content []Node, params *PageParams, opts ...RequestOption) (*Page, error) {
+	contentData, err := json.Marshal(&content)
+	if err != nil {
+		return nil, err
+	}
+	if err = params.validate("editPage", title, content, contentData); err != nil {
+		return nil, err
+	}
+
 	r := &request{
 		method:   http.MethodPost,
 		endpoint: fmt.Sprintf("%v/%v", "editPage", path),
 		secured:  true,
 	}
 	r.setFormParam("title", title)
-	contentData, err := json.Marshal(&content)
-	if err != nil {
-		return nil, err
-	}
 	r.setFormParam("content", string(contentData))
 	if params != nil {
 		if params.AuthorName != "" {
@@ -100,7 +100,7 @@ func (c *Client) EditPage(ctx context.Context, path, title string, content []Nod
 		return nil, err
 	}
 	if !page.OK {
-		return nil, errors.New(page.Error)
+		return nil, newAPIError(r.endpoint, page.Error)
 	}
 	return page.Result, nil
 }
@@ -114,8 +114,9 @@ type GetPageParams struct {
 // https://telegra.ph/api#getPage
 func (c *Client) GetPage(ctx context.Context, path string, option *GetPageParams, opts ...RequestOption) (*Page, error) {
 	r := &request{
-		method:   http.MethodPost,
-		endpoint: fmt.Sprintf("%v/%v", "getPage", path),
+		method:    http.MethodPost,
+		endpoint:  fmt.Sprintf("%v/%v", "getPage", path),
+		retryable: true,
 	}
 
 	if option != nil {
@@ -132,7 +133,7 @@ func (c *Client) GetPage(ctx context.Context, path string, option *GetPageParams
 		return nil, err
 	}
 	if !page.OK {
-		return nil, errors.New(page.Error)
+		return nil, newAPIError(r.endpoint, page.Error)
 	}
 	return page.Result, nil
 }
@@ -140,24 +141,29 @@ func (c *Client) GetPage(ctx context.Context, path string, option *GetPageParams
 type GetPageListParams struct {
 	// Offset Sequential number of the first page to be returned.
 	Offset int
-	// Limit Limits the number of pages to be retrieved.
+	// Limit Limits the number of pages to be retrieved, 0-200. The API returns 50 pages if zero.
 	Limit int
 }
 
 // GetPageList Use this method to get a list of pages belonging to a Telegraph account. Returns a PageList object, sorted by most recently created pages first.
 // https://telegra.ph/api#getPageList
 func (c *Client) GetPageList(ctx context.Context, params *GetPageListParams, opts ...RequestOption) (*PageList, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
 	r := &request{
-		method:   http.MethodPost,
-		endpoint: "getPageList",
-		secured:  true,
+		method:    http.MethodPost,
+		endpoint:  "getPageList",
+		retryable: true,
+		secured:   true,
 	}
 
 	if params != nil {
-		if params.Offset > 1 {
+		if params.Offset > 0 {
 			r.setFormParam("offset", params.Offset)
 		}
-		if params.Limit > 1 {
+		if params.Limit > 0 {
 			r.setFormParam("limit", params.Limit)
 		}
 	}
@@ -171,7 +177,7 @@ func (c *Client) GetPageList(ctx context.Context, params *GetPageListParams, opt
 		return nil, err
 	}
 	if !pageList.OK {
-		return nil, errors.New(pageList.Error)
+		return nil, newAPIError(r.endpoint, pageList.Error)
 	}
 	return pageList.Result, nil
 }
@@ -187,168 +193,45 @@ type GetViewsParams struct {
 	// Required if hour is passed. If passed, the number of page views for the requested day will be returned.
 	Day int64
 	// Hour (0-24)
-	// If passed, the number of page views for the requested hour will be returned.
-	Hour int64
+	// If passed, the number of page views for the requested hour will be returned. Passed if not nil, so
+	// that hour 0 can be requested.
+	Hour *int64
 }
 
 // GetViews Use this method to get the number of views for a Telegraph article.
 // Returns a PageViews object on success. By default, the total number of page views will be returned.
+// Out of range or missing required fields are reported with a ValidationError before any call is made.
 // https://telegra.ph/api#getViews
 func (c *Client) GetViews(ctx context.Context, path string, option *GetViewsParams, opts ...RequestOption) (*PageViews, error) {
-	r := &request{
-		method:   http.MethodPost,
-		endpoint: fmt.Sprintf("%v/%v", "getViews", path),
-	}
-
-	if option != nil {
-		if option.Year > 0 {
-			r.setFormParam("year", option.Year)
-		}
-		if option.Month > 0 {
-			r.setFormParam("month", option.Month)
-		}
-		if option.Day > 0 {
-			r.setFormParam("day", option.Day)
-		}
-		if option.Hour > 0 {
-			r.setFormParam("hour", option.Hour)
-		}
-	}
-	resp, err := c.callAPI(ctx, r, opts...)
-	if err != nil {
-		return nil, err
-	}
-	pageView := new(responsePageViews)
-	if err = json.Unmarshal(resp, pageView); err != nil {
-		return nil, err
-	}
-	if !pageView.OK {
-		return nil, errors.New(pageView.Error)
-	}
-	return pageView.Result, nil
+	return c.getViews(ctx, path, option.period(), opts...)
 }
 
-func (c *Client) UploadFiles(ctx context.Context, filenames []string, opts ...RequestOption) ([]string, error) {
-	files := make([]*os.File, 0, len(filenames))
-
-	// Close the file handle finished processing.
-	defer func() {
-		for _, file := range files {
-			file.Close()
-		}
-	}()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	for _, filename := range filenames {
-		file, err := os.Open(filename)
-		if err != nil {
-			return nil, err
-		}
-
-		files = append(files, file)
-
-		part, err := writer.CreateFormFile(fmt.Sprintf("%x", sha256.Sum256([]byte(filename))), filename)
-		if err != nil {
-			return nil, err
-		}
-
-		if _, err = io.Copy(part, file); err != nil {
-			return nil, err
-		}
-	}
-
-	if err := writer.Close(); err != nil {
+func (c *Client) getViews(ctx context.Context, path string, period viewsPeriod, opts ...RequestOption) (*PageViews, error) {
+	if err := period.validate(); err != nil {
 		return nil, err
 	}
 
 	r := &request{
-		method:   http.MethodPost,
-		endpoint: "upload",
-		body:     body,
+		method:    http.MethodPost,
+		endpoint:  fmt.Sprintf("%v/%v", "getViews", path),
+		retryable: true,
 	}
 
-	opts = append(opts, WithHeader("Content-Type", writer.FormDataContentType(), false))
-
-	cc := *c
-	cc.BaseURL = baseURL
-
-	resp, err := cc.callAPI(ctx, r, opts...)
-	if err != nil {
-		return nil, err
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	upload := make([]responseUpload, 0)
-	if err = json.Unmarshal(resp, &upload); err != nil {
-		m := map[string]string{}
-		if err = json.Unmarshal(resp, &m); err != nil {
-			return nil, err
+	for i, value := range period.values() {
+		if value != viewsUnset {
+			r.setFormParam(viewsFields[i].name, value)
 		}
-
-		return nil, errors.New(m["error"])
 	}
-
-	paths := make([]string, 0, len(upload))
-	for _, u := range upload {
-		paths = append(paths, u.Path)
-	}
-
-	return paths, nil
-}
-
-func (c *Client) Upload(ctx context.Context, filename string, content io.Reader, opts ...RequestOption) ([]string, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(fmt.Sprintf("%x", sha256.Sum256([]byte(filename))), filename)
-	if err != nil {
-		return nil, err
-	}
-	if _, err = io.Copy(part, content); err != nil {
-		return nil, err
-	}
-
-	if err = writer.Close(); err != nil {
-		return nil, err
-	}
-
-	r := &request{
-		method:   http.MethodPost,
-		endpoint: "upload",
-		body:     body,
-	}
-
-	opts = append(opts, WithHeader("Content-Type", writer.FormDataContentType(), false))
-
-	cc := *c
-	cc.BaseURL = baseURL
-	resp, err := cc.callAPI(ctx, r, opts...)
+	resp, err := c.callAPI(ctx, r, opts...)
 	if err != nil {
 		return nil, err
 	}
-
-	if err != nil {
+	pageView := new(responsePageViews)
+	if err = json.Unmarshal(resp, pageView); err != nil {
 		return nil, err
 	}
-
-	upload := make([]responseUpload, 0)
-	if err = json.Unmarshal(resp, &upload); err != nil {
-		m := map[string]string{}
-		if err = json.Unmarshal(resp, &m); err != nil {
-			return nil, err
-		}
-
-		return nil, errors.New(m["error"])
-	}
-
-	paths := make([]string, 0, len(upload))
-	for _, u := range upload {
-		paths = append(paths, u.Path)
+	if !pageView.OK {
+		return nil, newAPIError(r.endpoint, pageView.Error)
 	}
-
-	return paths, nil
+	return pageView.Result, nil
 }
@@ -0,0 +1,88 @@
+package telegraph
+
+import (
+	"context"
+	"iter"
+)
+
+// AllPagesParams configures AllPages. The zero value uses the defaults.
+type AllPagesParams struct {
+	// PageSize is the number of pages requested per call, MaxPageListLimit if not positive or above
+	// MaxPageListLimit.
+	PageSize int
+
+	// Offset is the number of most recent pages to skip.
+	Offset int
+}
+
+// AllPages iterates over the pages of the account, most recently created first, calling GetPageList
+// with opts as many times as needed. The next batch is requested while the current one is consumed.
+// Breaking out of the loop stops the iteration and cancels the pending call.
+//
+// An error ends the iteration: it is yielded with a zero Page.
+//
+//	for page, err := range client.AllPages(ctx, nil) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(page.Path)
+//	}
+func (c *Client) AllPages(ctx context.Context, params *AllPagesParams, opts ...RequestOption) iter.Seq2[Page, error] {
+	pageSize, offset := MaxPageListLimit, 0
+	if params != nil {
+		if params.PageSize > 0 {
+			pageSize = min(params.PageSize, MaxPageListLimit)
+		}
+		offset = max(params.Offset, 0)
+	}
+
+	return func(yield func(Page, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+
+		type batch struct {
+			list *PageList
+			err  error
+		}
+		fetch := func(offset int) chan batch {
+			ch := make(chan batch, 1)
+			go func() {
+				list, err := c.GetPageList(ctx, &GetPageListParams{Offset: offset, Limit: pageSize}, opts...)
+				ch <- batch{list: list, err: err}
+			}()
+			return ch
+		}
+
+		pending := fetch(offset)
+		defer func() {
+			cancel()
+			// Wait for the prefetch so that no call outlives the iteration.
+			if pending != nil {
+				<-pending
+			}
+		}()
+
+		for {
+			b := <-pending
+			pending = nil
+			if b.err != nil {
+				yield(Page{}, b.err)
+				return
+			}
+
+			offset += len(b.list.Pages)
+			more := len(b.list.Pages) == pageSize && offset < b.list.TotalCount
+			if more {
+				pending = fetch(offset)
+			}
+
+			for _, page := range b.list.Pages {
+				if !yield(page, nil) {
+					return
+				}
+			}
+			if !more {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package telegraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// stubPageList answers getPageList calls from an account of total pages, named "Page-N-12-15", and
+// records the forms of the calls.
+func stubPageList(c *Client, total int) func() []url.Values {
+	var mu sync.Mutex
+	var forms []url.Values
+	c.do = func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		forms = append(forms, form)
+		mu.Unlock()
+
+		offset, _ := strconv.Atoi(form.Get("offset"))
+		limit := 50
+		if form.Has("limit") {
+			limit, _ = strconv.Atoi(form.Get("limit"))
+		}
+		list := &PageList{TotalCount: total, Pages: []Page{}}
+		for i := offset; i < total && i < offset+limit; i++ {
+			list.Pages = append(list.Pages, Page{Path: fmt.Sprintf("Page-%d-12-15", i)})
+		}
+		data, err := json.Marshal(&responsePageList{response: response{OK: true}, Result: list})
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(string(data)))}, nil
+	}
+
+	return func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return forms
+	}
+}
+
+func TestGetPageListParams(t *testing.T) {
+	c := NewClient("token")
+	forms := stubPageList(c, 5)
+
+	list, err := c.GetPageList(context.Background(), &GetPageListParams{Offset: 1, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Pages) != 1 || list.Pages[0].Path != "Page-1-12-15" {
+		t.Errorf("unexpected pages: %#v", list.Pages)
+	}
+	if form := forms()[0]; form.Get("offset") != "1" || form.Get("limit") != "1" {
+		t.Errorf("offset and limit must be sent, got %v", form)
+	}
+
+	_, err = c.GetPageList(context.Background(), &GetPageListParams{Limit: MaxPageListLimit + 1})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Fields[0].Field != "limit" {
+		t.Errorf("limit must be validated, got: %v", err)
+	}
+
+	_, err = c.GetPageList(context.Background(), &GetPageListParams{Offset: -1})
+	if !errors.As(err, &vErr) || vErr.Fields[0].Field != "offset" {
+		t.Errorf("offset must be validated, got: %v", err)
+	}
+	if calls := len(forms()); calls != 1 {
+		t.Errorf("invalid parameters must not be sent, got %d calls", calls)
+	}
+}
+
+func TestAllPages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all pages", func(t *testing.T) {
+		c := NewClient("token")
+		forms := stubPageList(c, 7)
+
+		var paths []string
+		for page, err := range c.AllPages(ctx, &AllPagesParams{PageSize: 3, Offset: 1}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, page.Path)
+		}
+
+		if len(paths) != 6 || paths[0] != "Page-1-12-15" || paths[5] != "Page-6-12-15" {
+			t.Errorf("unexpected pages: %v", paths)
+		}
+		if calls := len(forms()); calls != 2 {
+			t.Errorf("expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("exact batches", func(t *testing.T) {
+		c := NewClient("token")
+		forms := stubPageList(c, 4)
+
+		n := 0
+		for _, err := range c.AllPages(ctx, &AllPagesParams{PageSize: 2}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			n++
+		}
+		if n != 4 || len(forms()) != 2 {
+			t.Errorf("expected 4 pages in 2 calls, got %d pages in %d calls", n, len(forms()))
+		}
+	})
+
+	t.Run("page size", func(t *testing.T) {
+		c := NewClient("token")
+		forms := stubPageList(c, 300)
+
+		n := 0
+		for _, err := range c.AllPages(ctx, &AllPagesParams{PageSize: 1000}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			n++
+		}
+		if n != 300 || len(forms()) != 2 || forms()[0].Get("limit") != strconv.Itoa(MaxPageListLimit) {
+			t.Errorf("page size must be capped, got %d pages in calls %v", n, forms())
+		}
+	})
+
+	t.Run("early termination", func(t *testing.T) {
+		c := NewClient("token")
+		forms := stubPageList(c, 100)
+
+		n := 0
+		for _, err := range c.AllPages(ctx, &AllPagesParams{PageSize: 2}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n++; n == 3 {
+				break
+			}
+		}
+		// The second batch is being consumed and the third one was prefetched.
+		if calls := len(forms()); calls != 3 {
+			t.Errorf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := NewClient("token")
+		stubResponses(c, `{"ok":false,"error":"ACCESS_TOKEN_INVALID"}`)
+
+		n := 0
+		for page, err := range c.AllPages(ctx, nil) {
+			n++
+			if !IsAccessTokenInvalid(err) || page.Path != "" {
+				t.Errorf("unexpected result: %#v, %v", page, err)
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected the error only, got %d results", n)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package telegraph
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter define a client-side limiter consulted before every HTTP call.
+// Wait blocks until the call may proceed or ctx is done.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a RateLimiter allowing rate calls per second on average, with bursts of up to burst calls.
+// It is safe for concurrent use, so a single bucket may be shared by several clients and goroutines.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+var _ RateLimiter = (*TokenBucket)(nil)
+
+// NewTokenBucket creates a full bucket refilled with rate tokens per second and holding at most burst tokens.
+// A bucket with a rate that is not positive is unlimited: Wait never blocks.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait takes a token from the bucket, sleeping until one is available.
+// Callers are served in the order they arrive; a canceled caller gives its token back.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !(b.rate > 0) {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	// Reserve the token right away, even if that puts the bucket in debt.
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// limiter returns the limiter that guards the host r is sent to.
+func (c *Client) limiter(r *request) RateLimiter {
+	if r.endpoint == "upload" {
+		return c.UploadLimiter
+	}
+	return c.APILimiter
+}
@@ -0,0 +1,80 @@
+package telegraph
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type countingLimiter struct {
+	calls atomic.Int32
+}
+
+func (l *countingLimiter) Wait(context.Context) error {
+	l.calls.Add(1)
+	return nil
+}
+
+func TestTokenBucket(t *testing.T) {
+	t.Run("burst then throttle", func(t *testing.T) {
+		b := NewTokenBucket(100, 2)
+		start := time.Now()
+		for range 4 {
+			if err := b.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Two calls fit in the burst, the other two wait 10ms each.
+		if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+			t.Errorf("calls must be throttled, took %s", elapsed)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		b := NewTokenBucket(0.001, 1)
+		_ = b.Wait(context.Background())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		defer cancel()
+		if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error must be a deadline, got: %v", err)
+		}
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		b := NewTokenBucket(0, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		for range 100 {
+			if err := b.Wait(ctx); err != nil {
+				t.Fatalf("a bucket without rate must not block, got: %v", err)
+			}
+		}
+	})
+
+	t.Run("separate budgets", func(t *testing.T) {
+		api, upload := new(countingLimiter), new(countingLimiter)
+		c := NewClient("token")
+		c.APILimiter = api
+		c.UploadLimiter = upload
+		stubResponses(c, `{"ok":true,"result":{"views":1}}`)
+
+		if _, err := c.GetViews(context.Background(), "Sample-Page-12-15", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		cc := *c
+		cc.do = func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("offline")
+		}
+		_, _ = cc.callAPI(context.Background(), &request{method: http.MethodPost, endpoint: "upload"})
+
+		if api.calls.Load() != 1 || upload.calls.Load() != 1 {
+			t.Errorf("unexpected limiter calls: api=%d upload=%d", api.calls.Load(), upload.calls.Load())
+		}
+	})
+}
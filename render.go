@@ -0,0 +1,88 @@
+package telegraph
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/html"
+)
+
+// RenderHTML writes nodes to w as escaped, well-formed HTML.
+// Elements with a tag not accepted by Telegraph are rendered as their children only, and attributes
+// other than href and src are skipped. Both typed *NodeElement trees and the map-based trees produced
+// by encoding/json are accepted. When an invalid node is met, the output rendered before it is written to
+// w and the error is returned.
+func RenderHTML(w io.Writer, nodes []Node) error {
+	bw := bufio.NewWriter(w)
+	for _, n := range nodes {
+		if err := renderHTML(bw, n); err != nil {
+			_ = bw.Flush()
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func renderHTML(w *bufio.Writer, n Node) error {
+	if text, ok := n.(string); ok {
+		_, err := w.WriteString(html.EscapeString(text))
+		return err
+	}
+
+	element, ok := asElement(n)
+	if !ok {
+		return errors.Wrapf(ErrInvalidDataType, "node of type %T", n)
+	}
+
+	tag := strings.ToLower(element.Tag)
+	if !IsAllowedTag(tag) {
+		return renderChildrenHTML(w, element.Children)
+	}
+
+	_ = w.WriteByte('<')
+	_, _ = w.WriteString(tag)
+	for _, key := range sortedAttrs(element.Attrs) {
+		_ = w.WriteByte(' ')
+		_, _ = w.WriteString(key)
+		_, _ = w.WriteString(`="`)
+		_, _ = w.WriteString(html.EscapeString(element.Attrs[key]))
+		_ = w.WriteByte('"')
+	}
+	_ = w.WriteByte('>')
+
+	if voidTags[tag] {
+		return nil
+	}
+
+	if err := renderChildrenHTML(w, element.Children); err != nil {
+		return err
+	}
+
+	_, _ = w.WriteString("</")
+	_, _ = w.WriteString(tag)
+	return w.WriteByte('>')
+}
+
+func renderChildrenHTML(w *bufio.Writer, children []Node) error {
+	for _, child := range children {
+		if err := renderHTML(w, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedAttrs returns the allowed attribute names of attrs in a stable order.
+func sortedAttrs(attrs map[string]string) []string {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		if IsAllowedAttr(key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
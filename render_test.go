@@ -0,0 +1,60 @@
+package telegraph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	t.Run("typed", func(t *testing.T) {
+		nodes := []Node{
+			&NodeElement{Tag: "p", Children: Nodes{
+				"1 < 2 & ",
+				&NodeElement{Tag: "a", Attrs: map[string]string{"href": `https://telegra.ph/?q="x"`, "onclick": "x()"}, Children: Nodes{"link"}},
+				&NodeElement{Tag: "br"},
+			}},
+			&NodeElement{Tag: "figure", Children: Nodes{
+				&NodeElement{Tag: "img", Attrs: map[string]string{"src": "/file/6a5b15e7eb4d7329ca7af.jpg"}},
+				&NodeElement{Tag: "figcaption", Children: Nodes{"caption"}},
+			}},
+			&NodeElement{Tag: "div", Children: Nodes{"unwrapped"}},
+		}
+
+		var sb strings.Builder
+		if err := RenderHTML(&sb, nodes); err != nil {
+			t.Fatal(err)
+		}
+		expected := `<p>1 &lt; 2 &amp; <a href="https://telegra.ph/?q=&#34;x&#34;">link</a><br></p>` +
+			`<figure><img src="/file/6a5b15e7eb4d7329ca7af.jpg"><figcaption>caption</figcaption></figure>unwrapped`
+		if sb.String() != expected {
+			t.Errorf("unexpected html:\n%s\n%s", sb.String(), expected)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		var nodes []Node
+		data := `[{"tag":"blockquote","children":["quote ",{"tag":"b","children":["bold"]}]}]`
+		if err := json.Unmarshal([]byte(data), &nodes); err != nil {
+			t.Fatal(err)
+		}
+
+		var sb strings.Builder
+		if err := RenderHTML(&sb, nodes); err != nil {
+			t.Fatal(err)
+		}
+		if sb.String() != `<blockquote>quote <b>bold</b></blockquote>` {
+			t.Errorf("unexpected html: %s", sb.String())
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var sb strings.Builder
+		if err := RenderHTML(&sb, []Node{P("kept"), 42}); err == nil {
+			t.Error("error must not be nil")
+		}
+		if sb.String() != `<p>kept</p>` {
+			t.Errorf("unexpected html: %s", sb.String())
+		}
+	})
+}
@@ -16,6 +16,16 @@ type request struct {
 	header   http.Header
 	body     io.Reader
 	fullURL  string
+	// contentLength is the length of body when it cannot be measured by net/http, 0 if unknown.
+	contentLength int64
+	// retryable marks calls that are safe to repeat, see RetryPolicy.
+	retryable bool
+	// retry is set by WithRetry and overrides retryable and RetryPolicy.RetryMutating, nil if not given.
+	retry *bool
+	// getBody returns a fresh copy of body for retries, nil if the body cannot be replayed.
+	getBody func() (io.Reader, error)
+	// progress is called while files are uploaded, see WithProgress.
+	progress ProgressFunc
 }
 
 // setFormParam set param with key/value to request form body
@@ -0,0 +1,152 @@
+package telegraph
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryPolicy define how failed API calls are retried. Retries are disabled when Client.Retry is nil.
+//
+// Only idempotent reads (GetPage, GetViews, GetPageList, GetAccountInfo) are retried unless
+// RetryMutating is set or the call is made with the WithRetry option.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled after every attempt. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff. Defaults to 30s.
+	MaxDelay time.Duration
+	// MaxFloodWait caps the delay accepted from a FLOOD_WAIT_N error. If the server asks to wait longer,
+	// the error is returned to the caller instead. Zero means no limit.
+	MaxFloodWait time.Duration
+	// RetryMutating enables retries for calls that create or modify data (createAccount, createPage, editPage, ...).
+	RetryMutating bool
+}
+
+// DefaultRetryPolicy returns a policy with three attempts and the default backoff.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:  3,
+		BaseDelay:    defaultRetryBaseDelay,
+		MaxDelay:     defaultRetryMaxDelay,
+		MaxFloodWait: time.Minute,
+	}
+}
+
+// WithRetry overrides whether a single call may be retried by the client retry policy, whatever the
+// kind of call and RetryPolicy.RetryMutating.
+func WithRetry(enabled bool) RequestOption {
+	return func(r *request) {
+		r.retry = &enabled
+	}
+}
+
+func (p *RetryPolicy) attempts(r *request) int {
+	if p == nil || p.MaxAttempts < 2 {
+		return 1
+	}
+
+	allowed := r.retryable || p.RetryMutating
+	if r.retry != nil {
+		allowed = *r.retry
+	}
+	if !allowed {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the jittered exponential delay before the given retry (starting from 1).
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	base, maxDelay := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := base
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	// Equal jitter: half of the delay is fixed, the other half is random.
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// shouldRetry decides whether a response or transport error is worth another attempt and how long to wait.
+func (p *RetryPolicy) shouldRetry(ctx context.Context, retry int, res *response, statusCode int, err error) (time.Duration, bool) {
+	if ctx.Err() != nil {
+		return 0, false
+	}
+
+	if err != nil {
+		return p.backoff(retry), isTransient(err)
+	}
+
+	if statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError {
+		return p.backoff(retry), true
+	}
+
+	if res != nil && !res.OK {
+		if delay, ok := IsFloodWait(newAPIError("", res.Error)); ok {
+			if p.MaxFloodWait > 0 && delay > p.MaxFloodWait {
+				return 0, false
+			}
+			return delay, true
+		}
+	}
+
+	return 0, false
+}
+
+// isTransient reports whether err is a network failure that may succeed on another attempt.
+func isTransient(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// peekResponse decodes the common envelope of an API response, if any.
+func peekResponse(data []byte) *response {
+	res := new(response)
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil
+	}
+	return res
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
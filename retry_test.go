@@ -0,0 +1,120 @@
+package telegraph
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func stubResponses(c *Client, bodies ...string) *int {
+	calls := new(int)
+	c.do = func(req *http.Request) (*http.Response, error) {
+		body := bodies[min(*calls, len(bodies)-1)]
+		*calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	}
+	return calls
+}
+
+func TestRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	t.Run("flood wait on read", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = policy
+		calls := stubResponses(c,
+			`{"ok":false,"error":"FLOOD_WAIT_0"}`,
+			`{"ok":true,"result":{"path":"Sample-Page-12-15","views":1}}`,
+		)
+
+		page, err := c.GetPage(context.Background(), "Sample-Page-12-15", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *calls != 2 || page.Path != "Sample-Page-12-15" {
+			t.Errorf("unexpected result after %d calls: %#v", *calls, page)
+		}
+	})
+
+	t.Run("mutating call is not retried", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = policy
+		calls := stubResponses(c, `{"ok":false,"error":"FLOOD_WAIT_0"}`)
+
+		_, err := c.CreatePage(context.Background(), "title", []Node{"text"}, nil)
+		if !errors.Is(err, ErrFloodWait) {
+			t.Errorf("error must be a flood wait, got: %v", err)
+		}
+		if *calls != 1 {
+			t.Errorf("call must not be retried, got %d calls", *calls)
+		}
+	})
+
+	t.Run("mutating call opted in", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = policy
+		calls := stubResponses(c, `{"ok":false,"error":"FLOOD_WAIT_0"}`)
+
+		_, err := c.CreatePage(context.Background(), "title", []Node{"text"}, nil, WithRetry(true))
+		if !errors.Is(err, ErrFloodWait) {
+			t.Errorf("error must be a flood wait, got: %v", err)
+		}
+		if *calls != policy.MaxAttempts {
+			t.Errorf("call must be attempted %d times, got %d", policy.MaxAttempts, *calls)
+		}
+	})
+
+	t.Run("call opted out", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, RetryMutating: true}
+		calls := stubResponses(c, `{"ok":false,"error":"FLOOD_WAIT_0"}`)
+
+		_, err := c.CreatePage(context.Background(), "title", []Node{"text"}, nil, WithRetry(false))
+		if !errors.Is(err, ErrFloodWait) {
+			t.Errorf("error must be a flood wait, got: %v", err)
+		}
+		if *calls != 1 {
+			t.Errorf("call must not be retried, got %d calls", *calls)
+		}
+	})
+
+	t.Run("flood wait above limit", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = &RetryPolicy{MaxAttempts: 3, MaxFloodWait: time.Second}
+		calls := stubResponses(c, `{"ok":false,"error":"FLOOD_WAIT_60"}`)
+
+		_, err := c.GetPageList(context.Background(), nil)
+		if delay, ok := IsFloodWait(err); !ok || delay != time.Minute {
+			t.Errorf("error must be a one minute flood wait, got: %v", err)
+		}
+		if *calls != 1 {
+			t.Errorf("call must not be retried, got %d calls", *calls)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		c := NewClient("token")
+		c.Retry = &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+		c.do = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadGateway,
+				Body:       io.NopCloser(strings.NewReader("bad gateway")),
+			}, nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := c.GetViews(ctx, "Sample-Page-12-15", nil)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error must be a deadline, got: %v", err)
+		}
+	})
+}
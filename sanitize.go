@@ -0,0 +1,122 @@
+package telegraph
+
+import (
+	"maps"
+	"strings"
+)
+
+// TagAction define what ContentFormat does with an element.
+type TagAction uint8
+
+const (
+	// TagUnwrap drops the element but keeps its children in its place.
+	TagUnwrap TagAction = iota
+	// TagDrop drops the element with all its content.
+	TagDrop
+	// TagRename keeps the element under another tag, which must be accepted by Telegraph.
+	TagRename
+)
+
+// TagRule define how an element is sanitized.
+type TagRule struct {
+	Action TagAction
+	// Tag is the new tag name for TagRename.
+	Tag string
+}
+
+var (
+	// UnwrapTag drops an element but keeps its children.
+	UnwrapTag = TagRule{Action: TagUnwrap}
+	// DropTag drops an element with all its content.
+	DropTag = TagRule{Action: TagDrop}
+)
+
+// RenameTag keeps an element under the tag name.
+func RenameTag(tag string) TagRule {
+	return TagRule{Action: TagRename, Tag: strings.ToLower(tag)}
+}
+
+// SanitizePolicy controls what ContentFormat does with each element.
+// Elements with a tag accepted by Telegraph are kept unless Rules has an entry for them, other
+// elements follow their entry in Rules, or Default.
+type SanitizePolicy struct {
+	// Rules maps lower-case tag names to the rule applied to them.
+	Rules map[string]TagRule
+	// Default is applied to the elements not accepted by Telegraph and missing from Rules.
+	// The zero value unwraps them.
+	Default TagRule
+}
+
+var defaultSanitizeRules = map[string]TagRule{
+	"h1":       RenameTag("h3"),
+	"h2":       RenameTag("h3"),
+	"h5":       RenameTag("h4"),
+	"h6":       RenameTag("h4"),
+	"div":      RenameTag("p"),
+	"del":      RenameTag("s"),
+	"strike":   RenameTag("s"),
+	"ins":      RenameTag("u"),
+	"span":     UnwrapTag,
+	"font":     UnwrapTag,
+	"script":   DropTag,
+	"style":    DropTag,
+	"head":     DropTag,
+	"title":    DropTag,
+	"noscript": DropTag,
+	"template": DropTag,
+}
+
+// DefaultSanitizePolicy returns the policy used by ContentFormat when none is given: h1 and h2 become h3,
+// h5 and h6 become h4, div becomes p, del and strike become s, ins becomes u, script, style and other
+// non-content elements are dropped, and any other unsupported element is unwrapped.
+// The returned policy is a copy that can be customized.
+func DefaultSanitizePolicy() *SanitizePolicy {
+	return &SanitizePolicy{
+		Rules:   maps.Clone(defaultSanitizeRules),
+		Default: UnwrapTag,
+	}
+}
+
+// WithSanitizePolicy makes ContentFormatWithOptions sanitize elements with policy instead of DefaultSanitizePolicy.
+func WithSanitizePolicy(policy *SanitizePolicy) ContentOption {
+	return contentOptionFunc(func(o *contentOptions) {
+		o.policy = policy
+	})
+}
+
+// rule returns the rule applied to tag.
+func (p *SanitizePolicy) rule(tag string) (TagRule, bool) {
+	if p != nil {
+		if rule, ok := p.Rules[tag]; ok {
+			return rule, true
+		}
+	}
+	if IsAllowedTag(tag) {
+		return TagRule{}, false
+	}
+	if p == nil {
+		return UnwrapTag, true
+	}
+	return p.Default, true
+}
+
+// sanitizedTag returns the tag an element is converted to, empty if it is unwrapped, and whether the
+// element is dropped with its content.
+func (p *SanitizePolicy) sanitizedTag(tag string) (string, bool) {
+	rule, ok := p.rule(tag)
+	if !ok {
+		return tag, false
+	}
+
+	switch rule.Action {
+	case TagDrop:
+		return "", true
+	case TagRename:
+		if IsAllowedTag(rule.Tag) {
+			return rule.Tag, false
+		}
+		return "", false
+	default:
+		return "", false
+	}
+}
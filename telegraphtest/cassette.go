@@ -0,0 +1,303 @@
+package telegraphtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the access tokens in recorded requests and responses, and the auth URLs in responses.
+const Redacted = "REDACTED"
+
+// ErrInteractionNotFound is returned in replay mode when no recorded interaction matches a request.
+var ErrInteractionNotFound = errors.New("telegraphtest: no recorded interaction matches the request")
+
+// Mode selects what a Recorder does with requests.
+type Mode uint8
+
+const (
+	// ModeReplay answers requests from the cassette, without network access.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real transport and records them in the cassette.
+	ModeRecord
+)
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request in normalized form, see Recorder.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	// Endpoint is the URL path without the leading slash, e.g. "getPage/Sample-Page-12-15" or "upload".
+	Endpoint string `json:"endpoint"`
+	// Form holds the query, form and multipart parameters.
+	Form url.Values `json:"form,omitempty"`
+}
+
+// RecordedResponse is a recorded response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+	// Encoding is "base64" when Body is not valid UTF-8 and was encoded, empty otherwise.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording requests and responses in a cassette file, or replaying
+// them from it, so that tests recorded once against a real account can run offline. It is plugged into
+// a client with:
+//
+//	client.HTTPClient = &http.Client{Transport: recorder}
+//
+// Requests are normalized before they are recorded and matched: access_token parameters are replaced
+// with Redacted, and multipart uploads are reduced to their fields and, for files, their name, content
+// type, size and SHA-256 digest, which removes the random boundary. Access tokens and auth URLs in JSON
+// responses are redacted as well. In replay mode, a request is answered with the first unused interaction
+// with the same method, endpoint and normalized form.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns a recorder using the cassette file at path. In replay mode the file is read and
+// must exist. In record mode requests are sent with transport, or http.DefaultTransport if nil, and the
+// cassette is written by Save.
+func NewRecorder(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, transport: transport}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, errors.Wrapf(err, "read cassette %s", path)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recorded, err := normalizeRequest(req, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded, body)
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		r.used[i] = true
+		return interaction.Response.response(req)
+	}
+	return nil, errors.Wrapf(ErrInteractionNotFound, "%s %s", req.Method, recorded.Endpoint)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+
+	res, err := r.transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response := RecordedResponse{StatusCode: res.StatusCode, Header: res.Header.Clone()}
+	response.Header.Del("Date")
+	response.Header.Del("Set-Cookie")
+	if utf8.Valid(data) {
+		response.Body = redactTokens(string(data))
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(data)
+		response.Encoding = "base64"
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{Request: recorded, Response: response})
+	r.mu.Unlock()
+
+	res.Body = io.NopCloser(bytes.NewReader(data))
+	return res, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == ModeReplay {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Unused returns the interactions of the cassette that were not replayed, e.g. to check that a test
+// made all the recorded calls.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if i < len(r.used) && !r.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+func (rr RecordedRequest) matches(other RecordedRequest) bool {
+	return rr.Method == other.Method && rr.Endpoint == other.Endpoint && rr.Form.Encode() == other.Form.Encode()
+}
+
+func (rr RecordedResponse) response(req *http.Request) (*http.Response, error) {
+	body := []byte(rr.Body)
+	if rr.Encoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rr.Body); err != nil {
+			return nil, errors.Wrap(err, "decode recorded body")
+		}
+	}
+
+	header := rr.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalizeRequest returns the recorded form of req, whose body has been read in body.
+func normalizeRequest(req *http.Request, body []byte) (RecordedRequest, error) {
+	recorded := RecordedRequest{
+		Method:   req.Method,
+		Endpoint: strings.TrimPrefix(req.URL.Path, "/"),
+		Form:     req.URL.Query(),
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return recorded, errors.Wrap(err, "parse form")
+		}
+		for key, value := range values {
+			recorded.Form[key] = append(recorded.Form[key], value...)
+		}
+
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return recorded, errors.Wrap(err, "parse multipart form")
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return recorded, errors.Wrap(err, "parse multipart form")
+			}
+			if part.FileName() == "" {
+				recorded.Form.Add(part.FormName(), string(data))
+				continue
+			}
+			sum := sha256.Sum256(data)
+			recorded.Form.Add(part.FormName(), fmt.Sprintf("%s; %s; %d bytes; sha256:%s",
+				part.FileName(), part.Header.Get("Content-Type"), len(data), hex.EncodeToString(sum[:])))
+		}
+	}
+
+	if values, ok := recorded.Form["access_token"]; ok {
+		for i := range values {
+			values[i] = Redacted
+		}
+	}
+	if len(recorded.Form) == 0 {
+		recorded.Form = nil
+	}
+	return recorded, nil
+}
+
+// secretFields matches the fields of JSON account objects that grant access to the account: the access
+// token and the auth_url login link.
+var secretFields = regexp.MustCompile(`"(access_token|auth_url)"\s*:\s*"(?:[^"\\]|\\.)*"`)
+
+// redactTokens replaces the access tokens and auth URLs of JSON account objects.
+func redactTokens(body string) string {
+	return secretFields.ReplaceAllString(body, `"$1":"`+Redacted+`"`)
+}
@@ -0,0 +1,97 @@
+package telegraphtest_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+	"github.com/teamdbnn/go-telegraph/telegraphtest"
+)
+
+// cassetteScenario creates an account, a page and uploads a file, returning the path of the page and of
+// the file.
+func cassetteScenario(ctx context.Context, client *telegraph.Client) (string, string, error) {
+	acc, err := client.CreateAccount(ctx, "Sandbox", nil)
+	if err != nil {
+		return "", "", err
+	}
+	client.AccessToken = acc.AccessToken
+
+	page, err := client.CreatePage(ctx, "Cassette", []telegraph.Node{"text"}, nil)
+	if err != nil {
+		return "", "", err
+	}
+	paths, err := client.Upload(ctx, "image.png", bytes.NewReader(append(pngHeader, "image"...)))
+	if err != nil {
+		return "", "", err
+	}
+	return page.Path, paths[0], nil
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+
+	srv := telegraphtest.NewServer()
+	recorder, err := telegraphtest.NewRecorder(cassette, telegraphtest.ModeRecord, srv.Server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := srv.Client("")
+	client.HTTPClient = &http.Client{Transport: recorder}
+
+	pagePath, filePath, err := cassetteScenario(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(client.AccessToken)) || bytes.Contains(data, []byte("/auth/")) {
+		t.Errorf("cassette must not contain the access token nor the auth URL:\n%s", data)
+	}
+	if !strings.Contains(string(data), "image.png; image/png; 21 bytes; sha256:") {
+		t.Errorf("upload must be normalized:\n%s", data)
+	}
+
+	// The server is closed: the calls are answered from the cassette.
+	replayer, err := telegraphtest.NewRecorder(cassette, telegraphtest.ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.AccessToken = ""
+	client.HTTPClient = &http.Client{Transport: replayer}
+
+	replayedPage, replayedFile, err := cassetteScenario(ctx, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayedPage != pagePath || replayedFile != filePath {
+		t.Errorf("got %q and %q, want %q and %q", replayedPage, replayedFile, pagePath, filePath)
+	}
+	if unused := replayer.Unused(); len(unused) != 0 {
+		t.Errorf("all interactions must be replayed, %d left", len(unused))
+	}
+
+	_, err = client.GetPage(ctx, pagePath, nil)
+	if !errors.Is(err, telegraphtest.ErrInteractionNotFound) {
+		t.Errorf("unrecorded call must fail, got: %v", err)
+	}
+	_, err = client.CreatePage(ctx, "Other title", []telegraph.Node{"text"}, nil)
+	if !errors.Is(err, telegraphtest.ErrInteractionNotFound) {
+		t.Errorf("call with another form must fail, got: %v", err)
+	}
+}
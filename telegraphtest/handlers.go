@@ -0,0 +1,512 @@
+package telegraphtest
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/teamdbnn/go-telegraph"
+)
+
+// handler implements an API method. It returns the result of a successful call, or an error code.
+type handler func(s *Server, call *apiCall) (any, string)
+
+var handlers = map[string]handler{
+	"createAccount":     (*Server).handleCreateAccount,
+	"editAccountInfo":   (*Server).handleEditAccountInfo,
+	"getAccountInfo":    (*Server).handleGetAccountInfo,
+	"revokeAccessToken": (*Server).handleRevokeAccessToken,
+	"createPage":        (*Server).handleCreatePage,
+	"editPage":          (*Server).handleEditPage,
+	"getPage":           (*Server).handleGetPage,
+	"getPageList":       (*Server).handleGetPageList,
+	"getViews":          (*Server).handleGetViews,
+}
+
+// securedMethods lists the methods that require a valid access token.
+var securedMethods = map[string]bool{
+	"editAccountInfo":   true,
+	"getAccountInfo":    true,
+	"revokeAccessToken": true,
+	"createPage":        true,
+	"editPage":          true,
+	"getPageList":       true,
+}
+
+// apiCall holds the parameters of a call.
+type apiCall struct {
+	form url.Values
+	// path is the page path given in the URL, as in /getPage/Sample-Page-12-15, or in the form.
+	path string
+	// account is the account of the access token, nil if no valid token was passed.
+	account *account
+}
+
+// Error codes returned by the server in addition to the ones defined by the telegraph package.
+const (
+	codeMethodNotFound = "METHOD_NOT_FOUND"
+	codeLimitInvalid   = "LIMIT_INVALID"
+	codeOffsetInvalid  = "OFFSET_INVALID"
+	codeYearRequired   = "YEAR_REQUIRED"
+	codeMonthRequired  = "MONTH_REQUIRED"
+	codeDayRequired    = "DAY_REQUIRED"
+	codeDateInvalid    = "DATE_INVALID"
+)
+
+const (
+	defaultPageListLimit = 50
+	maxPageListLimit     = 200
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/file/") {
+		s.serveFile(w, r)
+		return
+	}
+
+	method, path, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+
+	if message, ok := s.takeInjected(method); ok {
+		if method == "upload" {
+			writeJSON(w, map[string]string{"error": message})
+		} else {
+			writeJSON(w, map[string]any{"ok": false, "error": message})
+		}
+		return
+	}
+
+	if method == "upload" {
+		s.handleUpload(w, r)
+		return
+	}
+
+	h, ok := handlers[method]
+	if !ok {
+		writeJSON(w, map[string]any{"ok": false, "error": codeMethodNotFound})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	call := &apiCall{form: r.Form, path: path}
+	if call.path == "" {
+		call.path = r.Form.Get("path")
+	}
+	call.account = s.accounts[r.Form.Get("access_token")]
+	if securedMethods[method] && call.account == nil {
+		writeJSON(w, map[string]any{"ok": false, "error": telegraph.CodeAccessTokenInvalid})
+		return
+	}
+
+	result, code := h(s, call)
+	if code != "" {
+		writeJSON(w, map[string]any{"ok": false, "error": code})
+		return
+	}
+	writeJSON(w, map[string]any{"ok": true, "result": result})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, ok := s.files[r.URL.Path]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	_, _ = w.Write(data)
+}
+
+// checkLength returns code if value is not between minLen and maxLen characters long.
+func checkLength(value string, minLen, maxLen int, code string) string {
+	if n := utf8.RuneCountInString(value); n < minLen || n > maxLen {
+		return code
+	}
+	return ""
+}
+
+func firstCode(codes ...string) string {
+	for _, code := range codes {
+		if code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleCreateAccount(call *apiCall) (any, string) {
+	shortName := call.form.Get("short_name")
+	if shortName == "" {
+		return nil, telegraph.CodeShortNameRequired
+	}
+	if code := firstCode(
+		checkLength(shortName, 1, telegraph.MaxShortNameLength, telegraph.CodeShortNameTooLong),
+		checkLength(call.form.Get("author_name"), 0, telegraph.MaxAuthorNameLength, telegraph.CodeAuthorNameTooLong),
+		checkLength(call.form.Get("author_url"), 0, telegraph.MaxAuthorURLLength, telegraph.CodeAuthorURLTooLong),
+	); code != "" {
+		return nil, code
+	}
+
+	acc := s.createAccount(shortName, call.form.Get("author_name"), call.form.Get("author_url"))
+	return acc.info([]string{"short_name", "author_name", "author_url", "access_token", "auth_url"}), ""
+}
+
+func (s *Server) handleEditAccountInfo(call *apiCall) (any, string) {
+	if code := firstCode(
+		checkLength(call.form.Get("short_name"), 0, telegraph.MaxShortNameLength, telegraph.CodeShortNameTooLong),
+		checkLength(call.form.Get("author_name"), 0, telegraph.MaxAuthorNameLength, telegraph.CodeAuthorNameTooLong),
+		checkLength(call.form.Get("author_url"), 0, telegraph.MaxAuthorURLLength, telegraph.CodeAuthorURLTooLong),
+	); code != "" {
+		return nil, code
+	}
+
+	acc := call.account
+	if call.form.Has("short_name") && call.form.Get("short_name") != "" {
+		acc.shortName = call.form.Get("short_name")
+	}
+	if call.form.Has("author_name") {
+		acc.authorName = call.form.Get("author_name")
+	}
+	if call.form.Has("author_url") {
+		acc.authorURL = call.form.Get("author_url")
+	}
+	return acc.info(nil), ""
+}
+
+var accountFields = []string{"short_name", "author_name", "author_url", "auth_url", "page_count"}
+
+func (s *Server) handleGetAccountInfo(call *apiCall) (any, string) {
+	var fields []string
+	if raw := call.form.Get("fields"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+			return nil, "FIELDS_FORMAT_INVALID"
+		}
+		for _, field := range fields {
+			if !slices.Contains(accountFields, field) {
+				return nil, "FIELDS_FORMAT_INVALID"
+			}
+		}
+	}
+	return call.account.info(fields), ""
+}
+
+func (s *Server) handleRevokeAccessToken(call *apiCall) (any, string) {
+	acc := call.account
+	delete(s.accounts, acc.token)
+	acc.token = randomHex(30)
+	s.accounts[acc.token] = acc
+	// Like Telegraph, only the new access_token and auth_url are returned.
+	return acc.info([]string{"access_token", "auth_url"}), ""
+}
+
+// info returns the account fields, or the default ones if fields is empty.
+func (a *account) info(fields []string) map[string]any {
+	if len(fields) == 0 {
+		fields = []string{"short_name", "author_name", "author_url"}
+	}
+
+	info := make(map[string]any, len(fields))
+	for _, field := range fields {
+		switch field {
+		case "short_name":
+			info[field] = a.shortName
+		case "author_name":
+			info[field] = a.authorName
+		case "author_url":
+			info[field] = a.authorURL
+		case "access_token":
+			info[field] = a.token
+		case "auth_url":
+			info[field] = "https://edit.telegra.ph/auth/" + randomHex(20)
+		case "page_count":
+			info[field] = len(a.pages)
+		}
+	}
+	return info
+}
+
+// readPage reads and checks the title, content and author of a page.
+func readPage(call *apiCall, p *page) string {
+	title := call.form.Get("title")
+	if title == "" {
+		return telegraph.CodeTitleRequired
+	}
+	raw := call.form.Get("content")
+	if raw == "" {
+		return telegraph.CodeContentRequired
+	}
+	if code := firstCode(
+		checkLength(title, 1, telegraph.MaxTitleLength, telegraph.CodeTitleTooLong),
+		checkLength(call.form.Get("author_name"), 0, telegraph.MaxAuthorNameLength, telegraph.CodeAuthorNameTooLong),
+		checkLength(call.form.Get("author_url"), 0, telegraph.MaxAuthorURLLength, telegraph.CodeAuthorURLTooLong),
+	); code != "" {
+		return code
+	}
+	if len(raw) > telegraph.MaxContentSize {
+		return telegraph.CodeContentTooBig
+	}
+
+	var content telegraph.Nodes
+	if err := json.Unmarshal([]byte(raw), &content); err != nil || len(content) == 0 {
+		return telegraph.CodeContentFormatInvalid
+	}
+	for n := range telegraph.Descendants(content) {
+		if element, ok := n.(*telegraph.NodeElement); ok && !telegraph.IsAllowedTag(element.Tag) {
+			return telegraph.CodeContentFormatInvalid
+		}
+	}
+
+	p.title = title
+	p.content = content
+	p.authorName = call.form.Get("author_name")
+	p.authorURL = call.form.Get("author_url")
+	p.description, p.imageURL = summarize(content)
+	return ""
+}
+
+// summarize returns the beginning of the text of content and the first image, as shown in link previews.
+func summarize(content []telegraph.Node) (description, imageURL string) {
+	var sb strings.Builder
+	for n := range telegraph.Descendants(content) {
+		switch v := n.(type) {
+		case string:
+			if sb.Len() < 150 {
+				sb.WriteString(v)
+			}
+		case *telegraph.NodeElement:
+			if imageURL == "" && v.Tag == "img" {
+				imageURL = v.Attrs["src"]
+			}
+		}
+	}
+
+	description = strings.Join(strings.Fields(sb.String()), " ")
+	if runes := []rune(description); len(runes) > 150 {
+		description = string(runes[:150])
+	}
+	return description, imageURL
+}
+
+func (s *Server) handleCreatePage(call *apiCall) (any, string) {
+	p := &page{owner: call.account}
+	if code := readPage(call, p); code != "" {
+		return nil, code
+	}
+
+	p.path = s.pagePath(p.title, s.now())
+	s.pages[p.path] = p
+	call.account.pages = append([]string{p.path}, call.account.pages...)
+
+	return p.info(call.form.Get("return_content") == "true", call.account), ""
+}
+
+func (s *Server) handleEditPage(call *apiCall) (any, string) {
+	p, ok := s.pages[call.path]
+	if !ok {
+		return nil, telegraph.CodePageNotFound
+	}
+	if p.owner != call.account {
+		return nil, telegraph.CodePageAccessDenied
+	}
+
+	edited := *p
+	if code := readPage(call, &edited); code != "" {
+		return nil, code
+	}
+	*p = edited
+
+	return p.info(call.form.Get("return_content") == "true", call.account), ""
+}
+
+func (s *Server) handleGetPage(call *apiCall) (any, string) {
+	p, ok := s.pages[call.path]
+	if !ok {
+		return nil, telegraph.CodePageNotFound
+	}
+	return p.info(call.form.Get("return_content") == "true", call.account), ""
+}
+
+func (s *Server) handleGetPageList(call *apiCall) (any, string) {
+	offset, limit := 0, defaultPageListLimit
+	if raw := call.form.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return nil, codeOffsetInvalid
+		}
+		offset = n
+	}
+	if raw := call.form.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxPageListLimit {
+			return nil, codeLimitInvalid
+		}
+		limit = n
+	}
+
+	paths := call.account.pages
+	pages := make([]map[string]any, 0, limit)
+	for i := offset; i < len(paths) && i < offset+limit; i++ {
+		pages = append(pages, s.pages[paths[i]].info(false, call.account))
+	}
+
+	return map[string]any{"total_count": len(paths), "pages": pages}, ""
+}
+
+func (s *Server) handleGetViews(call *apiCall) (any, string) {
+	p, ok := s.pages[call.path]
+	if !ok {
+		return nil, telegraph.CodePageNotFound
+	}
+
+	// Each unit requires the larger ones, as documented.
+	units := []struct {
+		name     string
+		min, max int
+		required string
+	}{
+		{"year", 2000, 2100, ""},
+		{"month", 1, 12, codeYearRequired},
+		{"day", 1, 31, codeMonthRequired},
+		{"hour", 0, 24, codeDayRequired},
+	}
+	var values []int
+	for i, unit := range units {
+		raw := call.form.Get(unit.name)
+		if raw == "" {
+			continue
+		}
+		if len(values) != i {
+			return nil, unit.required
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < unit.min || n > unit.max {
+			return nil, codeDateInvalid
+		}
+		values = append(values, n)
+	}
+
+	views := 0
+	for _, at := range p.views {
+		at = at.UTC()
+		fields := []int{at.Year(), int(at.Month()), at.Day(), at.Hour()}
+		if slices.Equal(fields[:len(values)], values) {
+			views++
+		}
+	}
+	return map[string]any{"views": views}, ""
+}
+
+// info returns the page fields. can_edit is set when an access token was passed.
+func (p *page) info(withContent bool, caller *account) map[string]any {
+	info := map[string]any{
+		"path":        p.path,
+		"url":         "https://telegra.ph/" + p.path,
+		"title":       p.title,
+		"description": p.description,
+		"views":       len(p.views),
+	}
+	if p.authorName != "" {
+		info["author_name"] = p.authorName
+	}
+	if p.authorURL != "" {
+		info["author_url"] = p.authorURL
+	}
+	if p.imageURL != "" {
+		info["image_url"] = p.imageURL
+	}
+	if withContent {
+		info["content"] = p.content
+	}
+	if caller != nil {
+		info["can_edit"] = caller == p.owner
+	}
+	return info
+}
+
+// Upload errors, returned in the "error" field of the response like telegra.ph does.
+const (
+	uploadFileTooBig    = "File is too big"
+	uploadFileTypeError = "File type invalid"
+	uploadNoFilesError  = "No files passed"
+	uploadFormatError   = "Invalid form"
+)
+
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeJSON(w, map[string]string{"error": uploadFormatError})
+		return
+	}
+
+	var files []map[string]string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeJSON(w, map[string]string{"error": uploadFormatError})
+			return
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, telegraph.MaxUploadSize+1))
+		if err != nil {
+			writeJSON(w, map[string]string{"error": uploadFormatError})
+			return
+		}
+		if len(data) > telegraph.MaxUploadSize {
+			writeJSON(w, map[string]string{"error": uploadFileTooBig})
+			return
+		}
+		contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		if !slices.Contains(telegraph.UploadContentTypes, contentType) {
+			writeJSON(w, map[string]string{"error": uploadFileTypeError})
+			return
+		}
+
+		_, ext, _ := strings.Cut(contentType, "/")
+		if ext == "jpeg" {
+			ext = "jpg"
+		}
+		path := "/file/" + randomHex(10) + "." + ext
+		s.files[path] = data
+		files = append(files, map[string]string{"src": path})
+	}
+
+	if len(files) == 0 {
+		writeJSON(w, map[string]string{"error": uploadNoFilesError})
+		return
+	}
+	writeJSON(w, files)
+}
+
+// now returns the current time of the server.
+func (s *Server) now() time.Time {
+	if s.Now == nil {
+		return time.Now()
+	}
+	return s.Now()
+}
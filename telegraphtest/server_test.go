@@ -0,0 +1,282 @@
+package telegraphtest_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/teamdbnn/go-telegraph"
+	"github.com/teamdbnn/go-telegraph/telegraphtest"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+func TestAccount(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	acc, err := srv.Client("").CreateAccount(ctx, "Sandbox", &telegraph.CreateAccountParams{AuthorName: "Anonymous"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.ShortName != "Sandbox" || acc.AuthorName != "Anonymous" || acc.AccessToken == "" || acc.AuthURL == "" {
+		t.Fatalf("unexpected account: %#v", acc)
+	}
+
+	client := srv.Client(acc.AccessToken)
+	edited, err := client.EditAccountInfo(ctx, &telegraph.EditAccountInfoParams{AuthorURL: "https://t.me/sandbox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.ShortName != "Sandbox" || edited.AuthorURL != "https://t.me/sandbox" {
+		t.Errorf("unexpected edited account: %#v", edited)
+	}
+
+	info, err := client.GetAccountInfo(ctx, &telegraph.GetAccountInfoOption{Fields: []string{"short_name", "page_count"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ShortName != "Sandbox" || info.AuthorName != "" || info.PageCount != 0 {
+		t.Errorf("unexpected account info: %#v", info)
+	}
+
+	revoked, err := client.RevokeAccessToken(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked.AccessToken == "" || revoked.AccessToken == acc.AccessToken {
+		t.Fatalf("access token must change, got %q", revoked.AccessToken)
+	}
+
+	_, err = client.GetAccountInfo(ctx, nil)
+	if !telegraph.IsAccessTokenInvalid(err) {
+		t.Errorf("revoked token must be rejected, got: %v", err)
+	}
+	if _, err = srv.Client(revoked.AccessToken).GetAccountInfo(ctx, nil); err != nil {
+		t.Errorf("new token must be accepted, got: %v", err)
+	}
+
+	_, err = srv.Client("").CreateAccount(ctx, "", nil)
+	if err == nil {
+		t.Error("account without short name must be rejected")
+	}
+}
+
+func TestPages(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	srv.Now = func() time.Time { return time.Date(2024, 12, 15, 10, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	owner := srv.Client(srv.NewAccount("Owner"))
+	other := srv.Client(srv.NewAccount("Other"))
+	content := []telegraph.Node{
+		telegraph.P("Hello, ", telegraph.B("world"), "!"),
+		telegraph.Img("/file/abc.png"),
+	}
+
+	page, err := owner.CreatePage(ctx, "Sample Page", content, &telegraph.PageParams{AuthorName: "Anonymous"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Path != "Sample-Page-12-15" || page.Description != "Hello, world!" || page.ImageURL != "/file/abc.png" {
+		t.Errorf("unexpected page: %#v", page)
+	}
+	if !page.CanEdit || page.Content != nil {
+		t.Errorf("page must be editable and without content: %#v", page)
+	}
+
+	second, err := owner.CreatePage(ctx, "Sample Page!", content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Path != "Sample-Page-12-15-2" {
+		t.Errorf("second path must be numbered, got %q", second.Path)
+	}
+
+	got, err := srv.Client("").GetPage(ctx, page.Path, &telegraph.GetPageParams{ReturnContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Sample Page" || len(got.Content) != 2 || got.CanEdit {
+		t.Errorf("unexpected page: %#v", got)
+	}
+
+	_, err = other.EditPage(ctx, page.Path, "Stolen", content, nil)
+	if !errors.Is(err, telegraph.ErrPageAccessDenied) {
+		t.Errorf("edit by another account must be denied, got: %v", err)
+	}
+	edited, err := owner.EditPage(ctx, page.Path, "Edited", content, &telegraph.PageParams{ReturnContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edited.Path != page.Path || edited.Title != "Edited" || edited.AuthorName != "" || len(edited.Content) != 2 {
+		t.Errorf("unexpected edited page: %#v", edited)
+	}
+
+	_, err = owner.GetPage(ctx, "Missing-12-15", nil)
+	if !telegraph.IsNotFound(err) {
+		t.Errorf("missing page must not be found, got: %v", err)
+	}
+
+	third, err := owner.CreatePage(ctx, "Third", content, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := owner.GetPageList(ctx, &telegraph.GetPageListParams{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.TotalCount != 3 || len(list.Pages) != 2 || list.Pages[0].Path != third.Path || list.Pages[1].Path != second.Path {
+		t.Errorf("unexpected page list: %#v", list)
+	}
+	list, err = other.GetPageList(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.TotalCount != 0 || len(list.Pages) != 0 {
+		t.Errorf("other account must have no pages: %#v", list)
+	}
+}
+
+func TestPageValidation(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	token := srv.NewAccount("Sandbox")
+
+	tests := []struct {
+		name    string
+		form    string
+		message string
+	}{
+		{"no token", "title=T&content=%5B%22x%22%5D", telegraph.CodeAccessTokenInvalid},
+		{"no title", "access_token=" + token + "&content=%5B%22x%22%5D", telegraph.CodeTitleRequired},
+		{"no content", "access_token=" + token + "&title=T", telegraph.CodeContentRequired},
+		{"invalid content", "access_token=" + token + "&title=T&content=%7B%7D", telegraph.CodeContentFormatInvalid},
+		{"invalid tag", "access_token=" + token + "&title=T&content=%5B%7B%22tag%22%3A%22div%22%7D%5D", telegraph.CodeContentFormatInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL+"/createPage", bytes.NewBufferString(tt.form))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			res, err := srv.Server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+			if want := `{"error":"` + tt.message + `","ok":false}` + "\n"; string(body) != want {
+				t.Errorf("got %s, want %s", body, want)
+			}
+		})
+	}
+}
+
+func TestInjectError(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.Client(srv.NewAccount("Sandbox"))
+
+	srv.InjectError("getAccountInfo", "FLOOD_WAIT_7")
+
+	_, err := client.GetAccountInfo(ctx, nil)
+	if delay, ok := telegraph.IsFloodWait(err); !ok || delay != 7*time.Second {
+		t.Errorf("expected a 7s flood wait, got: %v", err)
+	}
+	if _, err = client.GetAccountInfo(ctx, nil); err != nil {
+		t.Errorf("injected error must be consumed, got: %v", err)
+	}
+	if calls := srv.Calls("getAccountInfo"); calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+
+	client.Retry = &telegraph.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	srv.InjectError("", "FLOOD_WAIT_0")
+	if _, err = client.GetAccountInfo(ctx, nil); err != nil {
+		t.Errorf("call must be retried, got: %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.Client("")
+
+	content := append(pngHeader, "image"...)
+	result, err := client.UploadDetailed(ctx, "image.png", bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.URL != srv.URL+result.Src {
+		t.Errorf("unexpected URL %q for %q", result.URL, result.Src)
+	}
+	if data, ok := srv.File(result.Src); !ok || !bytes.Equal(data, content) {
+		t.Errorf("unexpected file content: %q", data)
+	}
+
+	res, err := srv.Server.Client().Get(result.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if data, _ := io.ReadAll(res.Body); !bytes.Equal(data, content) {
+		t.Errorf("unexpected served content: %q", data)
+	}
+
+	srv.InjectError("upload", "File type invalid")
+	if _, err = client.Upload(ctx, "image.png", bytes.NewReader(content)); err == nil {
+		t.Error("injected upload error must be returned")
+	}
+}
+
+func TestViews(t *testing.T) {
+	srv := telegraphtest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	client := srv.Client(srv.NewAccount("Sandbox"))
+
+	page, err := client.CreatePage(ctx, "Views", []telegraph.Node{"text"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddViews(page.Path, time.Date(2024, 12, 15, 10, 30, 0, 0, time.UTC), 3)
+	srv.AddViews(page.Path, time.Date(2024, 12, 15, 11, 0, 0, 0, time.UTC), 2)
+	srv.AddViews(page.Path, time.Date(2024, 11, 2, 0, 0, 0, 0, time.UTC), 1)
+	if srv.AddViews("Missing-12-15", time.Now(), 1) {
+		t.Error("views must not be added to a missing page")
+	}
+
+	hour := int64(10)
+	tests := []struct {
+		params *telegraph.GetViewsParams
+		views  int
+	}{
+		{nil, 6},
+		{&telegraph.GetViewsParams{Year: 2024}, 6},
+		{&telegraph.GetViewsParams{Year: 2024, Month: 12}, 5},
+		{&telegraph.GetViewsParams{Year: 2024, Month: 12, Day: 15, Hour: &hour}, 3},
+		{&telegraph.GetViewsParams{Year: 2023}, 0},
+	}
+	for _, tt := range tests {
+		views, err := client.GetViews(ctx, page.Path, tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if views.Views != tt.views {
+			t.Errorf("%+v: got %d views, want %d", tt.params, views.Views, tt.views)
+		}
+	}
+}
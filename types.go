@@ -1,5 +1,10 @@
 package telegraph
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 type response struct {
 	OK    bool   `json:"ok"`
 	Error string `json:"error,omitempty"`
@@ -88,7 +93,7 @@ type Page struct {
 	ImageURL string `json:"image_url,omitempty"`
 
 	// Content of the page.
-	Content []Node `json:"content,omitempty"`
+	Content Nodes `json:"content,omitempty"`
 
 	// Number of page views for the page.
 	Views int `json:"views"`
@@ -109,6 +114,51 @@ type Node any
 
 var _ Node = &NodeElement{}
 
+// Nodes is a list of DOM nodes. When decoded from JSON, text nodes become string values and elements
+// become *NodeElement values, recursively, instead of the generic map[string]any.
+type Nodes []Node
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Nodes) UnmarshalJSON(data []byte) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	nodes := make(Nodes, 0, len(raw))
+	for _, item := range raw {
+		node, err := decodeNode(item)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+	*n = nodes
+
+	return nil
+}
+
+func decodeNode(data json.RawMessage) (Node, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, err
+		}
+		return text, nil
+	}
+
+	element := new(NodeElement)
+	if err := json.Unmarshal(data, element); err != nil {
+		return nil, err
+	}
+	return element, nil
+}
+
 // NodeElement represents a DOM element node.
 type NodeElement struct {
 	// Name of the DOM element. Available tags: a, aside, b, blockquote, br, code, em, figcaption, figure,
@@ -120,5 +170,5 @@ type NodeElement struct {
 	Attrs map[string]string `json:"attrs,omitempty"`
 
 	// List of child nodes for the DOM element.
-	Children []Node `json:"children,omitempty"`
+	Children Nodes `json:"children,omitempty"`
 }
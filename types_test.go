@@ -0,0 +1,47 @@
+package telegraph
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNodesUnmarshalJSON(t *testing.T) {
+	data := []byte(`{
+		"path": "Sample-Page-12-15",
+		"content": [
+			{"tag": "p", "children": ["Hello, ", {"tag": "a", "attrs": {"href": "https://telegra.ph/"}, "children": ["World"]}, "!"]},
+			"tail"
+		]
+	}`)
+
+	page := new(Page)
+	if err := json.Unmarshal(data, page); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Nodes{
+		&NodeElement{Tag: "p", Children: Nodes{
+			"Hello, ",
+			&NodeElement{Tag: "a", Attrs: map[string]string{"href": "https://telegra.ph/"}, Children: Nodes{"World"}},
+			"!",
+		}},
+		"tail",
+	}
+	if !reflect.DeepEqual(page.Content, expected) {
+		t.Errorf("unexpected content: %#v", page.Content)
+	}
+
+	// Encoding the decoded content again must give back the same JSON.
+	encoded, err := json.Marshal(page.Content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Nodes
+	if err = json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Errorf("content must survive a round trip, got: %#v", decoded)
+	}
+}
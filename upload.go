@@ -0,0 +1,602 @@
+package telegraph
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// sniffLen is the number of bytes read by http.DetectContentType.
+const sniffLen = 512
+
+// FileError describes a file rejected before it is uploaded.
+type FileError struct {
+	// Filename is the name of the file given to Upload or UploadFiles.
+	Filename string
+	// Size is the size of the file in bytes, or the number of bytes read when the limit was exceeded.
+	Size int64
+	// ContentType is the media type detected from the content of the file.
+	ContentType string
+	// Err is ErrFileTooBig or ErrFileType.
+	Err error
+}
+
+func (e FileError) Error() string {
+	switch {
+	case errors.Is(e.Err, ErrFileTooBig):
+		return fmt.Sprintf("%s: %v: %d bytes, limit is %d", e.Filename, e.Err, e.Size, MaxUploadSize)
+	case errors.Is(e.Err, ErrFileType):
+		return fmt.Sprintf("%s: %v %s, expected one of %s", e.Filename, e.Err, e.ContentType,
+			strings.Join(UploadContentTypes, ", "))
+	default:
+		return fmt.Sprintf("%s: %v", e.Filename, e.Err)
+	}
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// UploadResult describes an uploaded file.
+type UploadResult struct {
+	// Filename is the name of the file given to the upload method.
+	Filename string
+	// Src is the path of the uploaded file, to use as src attribute, e.g. "/file/6a5b15e7eb4d7329ca7af.jpg".
+	Src string
+	// URL is the absolute URL of the uploaded file, e.g. "https://telegra.ph/file/6a5b15e7eb4d7329ca7af.jpg".
+	URL string
+	// Size is the size of the file in bytes, -1 if unknown.
+	Size int64
+	// ContentType is the media type detected from the content of the file.
+	ContentType string
+	// Err is set when the file was not uploaded, see UploadFilesDetailed.
+	Err error
+}
+
+// UploadProgress reports how much of an upload has been sent.
+type UploadProgress struct {
+	// Filename is the name of the file being sent.
+	Filename string
+	// File is the index of the file being sent among the uploaded files.
+	File int
+	// Sent is the number of bytes of the file sent so far.
+	Sent int64
+	// Size is the size of the file, -1 if unknown.
+	Size int64
+	// TotalSent is the number of bytes of all files sent so far.
+	TotalSent int64
+	// TotalSize is the size of all files, -1 if the size of a file is unknown.
+	TotalSize int64
+}
+
+// ProgressFunc receives the progress of an upload.
+type ProgressFunc func(p UploadProgress)
+
+// WithProgress makes Upload and UploadFiles call f when they start sending a file and each time a chunk
+// of it is sent. f is called sequentially from the goroutine writing the request body, it must return
+// quickly. When the upload is retried, the progress starts over.
+func WithProgress(f ProgressFunc) RequestOption {
+	return func(r *request) {
+		r.progress = f
+	}
+}
+
+// UploadError is returned when files do not fit the limits of telegra.ph/upload. Files rejected by the
+// preflight checks are all listed and nothing is sent; a file of unknown size found to exceed
+// MaxUploadSize while it is sent aborts the request. It also lists the sent files missing from the
+// response of telegra.ph/upload.
+type UploadError struct {
+	Files []FileError
+}
+
+func (e *UploadError) Error() string {
+	msgs := make([]string, 0, len(e.Files))
+	for _, f := range e.Files {
+		msgs = append(msgs, f.Error())
+	}
+	return "upload: files not uploaded: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the files, so that errors.Is(err, ErrFileTooBig) reports whether any file
+// is too big.
+func (e *UploadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Files))
+	for _, f := range e.Files {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// uploadFile is a file sent to telegra.ph/upload.
+type uploadFile struct {
+	// name is the filename sent in the multipart form.
+	name string
+	// size is the length of the content, -1 if unknown.
+	size int64
+	// contentType is the media type detected from the content.
+	contentType string
+	// open returns the content of the file. It is called again when the request is replayed.
+	open func() (io.ReadCloser, error)
+}
+
+// UploadFiles uploads the files at filenames to telegra.ph and returns their paths, in the same order.
+// The files are checked before anything is sent: an *UploadError lists the files that are larger than
+// MaxUploadSize or whose content is not of one of the UploadContentTypes. The files are then streamed from
+// disk while the request is sent, and read again if it is retried.
+func (c *Client) UploadFiles(ctx context.Context, filenames []string, opts ...RequestOption) ([]string, error) {
+	files := make([]uploadFile, 0, len(filenames))
+	var rejected []FileError
+	for _, filename := range filenames {
+		file, err := inspectFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if ferr := file.check(); ferr != nil {
+			rejected = append(rejected, *ferr)
+			continue
+		}
+		files = append(files, file)
+	}
+	if len(rejected) > 0 {
+		return nil, &UploadError{Files: rejected}
+	}
+
+	results, err := c.upload(ctx, files, true, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return uploadedPaths(results)
+}
+
+// UploadFilesDetailed uploads the files at filenames like UploadFiles, but sends the valid files even when
+// others are rejected, and returns a result for each of filenames, in the same order. The Err field of a
+// result is set when the file was not uploaded: a FileError for rejected files, the error opening the file
+// or the error of the upload request. The returned error is only set when the upload request failed.
+func (c *Client) UploadFilesDetailed(ctx context.Context, filenames []string, opts ...RequestOption) ([]UploadResult, error) {
+	results := make([]UploadResult, len(filenames))
+	files := make([]uploadFile, 0, len(filenames))
+	// sentIndexes maps the sent files to their index in filenames.
+	sentIndexes := make([]int, 0, len(filenames))
+
+	for i, filename := range filenames {
+		results[i] = UploadResult{Filename: filename, Size: -1}
+
+		file, err := inspectFile(filename)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Size = file.size
+		results[i].ContentType = file.contentType
+		if ferr := file.check(); ferr != nil {
+			results[i].Err = *ferr
+			continue
+		}
+		files = append(files, file)
+		sentIndexes = append(sentIndexes, i)
+	}
+	if len(files) == 0 {
+		return results, nil
+	}
+
+	sent, err := c.upload(ctx, files, true, opts...)
+	for k, i := range sentIndexes {
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i] = sent[k]
+	}
+
+	return results, err
+}
+
+// inspectFile measures the file at filename and detects its media type.
+func inspectFile(filename string) (uploadFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return uploadFile{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return uploadFile{}, err
+	}
+	head, err := readHead(f)
+	if err != nil {
+		return uploadFile{}, err
+	}
+
+	return uploadFile{
+		name:        filename,
+		size:        info.Size(),
+		contentType: sniffContentType(head),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(filename)
+		},
+	}, nil
+}
+
+// Upload uploads content to telegra.ph under filename and returns its path. The media type is detected
+// from the first bytes of content and checked, as well as the size when content has a Len method, such as
+// *bytes.Reader; an *UploadError is returned for invalid files. content is then streamed while the
+// request is sent, it is read only once.
+func (c *Client) Upload(ctx context.Context, filename string, content io.Reader, opts ...RequestOption) ([]string, error) {
+	result, err := c.UploadDetailed(ctx, filename, content, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return uploadedPaths([]UploadResult{*result})
+}
+
+// UploadDetailed uploads content like Upload and returns the result of the upload.
+func (c *Client) UploadDetailed(ctx context.Context, filename string, content io.Reader, opts ...RequestOption) (*UploadResult, error) {
+	size := int64(-1)
+	if sized, ok := content.(interface{ Len() int }); ok {
+		size = int64(sized.Len())
+	}
+	head, err := readHead(content)
+	if err != nil {
+		return nil, err
+	}
+
+	file := uploadFile{
+		name:        filename,
+		size:        size,
+		contentType: sniffContentType(head),
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(io.MultiReader(bytes.NewReader(head), content)), nil
+		},
+	}
+	if ferr := file.check(); ferr != nil {
+		return nil, &UploadError{Files: []FileError{*ferr}}
+	}
+
+	results, err := c.upload(ctx, []uploadFile{file}, false, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uploadedPaths(results); err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// readHead reads the bytes used to detect the media type of r.
+func readHead(r io.Reader) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// sniffContentType returns the media type of content, without parameters.
+func sniffContentType(head []byte) string {
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return contentType
+}
+
+// check returns an error if the file does not fit the upload limits.
+func (f *uploadFile) check() *FileError {
+	switch {
+	case f.size > MaxUploadSize:
+		return &FileError{Filename: f.name, Size: f.size, ContentType: f.contentType, Err: ErrFileTooBig}
+	case !slices.Contains(UploadContentTypes, f.contentType):
+		return &FileError{Filename: f.name, Size: f.size, ContentType: f.contentType, Err: ErrFileType}
+	default:
+		return nil
+	}
+}
+
+// upload sends files in a multipart form streamed through a pipe, so that they are never held in memory.
+// The request is replayed on retry only if replayable is set, as the files are opened again.
+// Telegraph returns the paths of the files in the order of the parts, the results follow the files.
+func (c *Client) upload(ctx context.Context, files []uploadFile, replayable bool, opts ...RequestOption) ([]UploadResult, error) {
+	// The body is written before callAPI applies the options, read the progress hook from a scratch request.
+	scratch := new(request)
+	for _, opt := range opts {
+		opt(scratch)
+	}
+
+	stream := &multipartStream{
+		ctx:      ctx,
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+		files:    files,
+		progress: scratch.progress,
+	}
+	body := stream.open()
+	// Stop the writer if the request fails before the body is sent.
+	defer body.Close()
+
+	r := &request{
+		method:        http.MethodPost,
+		endpoint:      "upload",
+		body:          body,
+		contentLength: stream.length(),
+	}
+	if replayable {
+		r.getBody = func() (io.Reader, error) {
+			return stream.open(), nil
+		}
+	}
+
+	opts = append(opts, WithHeader("Content-Type", "multipart/form-data; boundary="+stream.boundary, false))
+
+	cc := *c
+	cc.BaseURL = c.uploadURL()
+
+	resp, err := cc.callAPI(ctx, r, opts...)
+	if err != nil {
+		if ferr := stream.fileError(); ferr != nil {
+			return nil, &UploadError{Files: []FileError{*ferr}}
+		}
+		return nil, err
+	}
+
+	upload := make([]responseUpload, 0)
+	if err = json.Unmarshal(resp, &upload); err != nil {
+		m := map[string]string{}
+		if err = json.Unmarshal(resp, &m); err != nil {
+			return nil, err
+		}
+
+		return nil, newAPIError(r.endpoint, m["error"])
+	}
+
+	sent := stream.sentSizes()
+	results := make([]UploadResult, len(files))
+	for i, file := range files {
+		results[i] = UploadResult{
+			Filename:    file.name,
+			Size:        sent[i],
+			ContentType: file.contentType,
+		}
+		if i >= len(upload) {
+			results[i].Err = ErrUploadMissing
+			continue
+		}
+		results[i].Src = upload[i].Path
+		results[i].URL = cc.BaseURL + strings.TrimPrefix(upload[i].Path, "/")
+	}
+
+	return results, nil
+}
+
+// uploadedPaths returns the paths of the uploaded files, in the order of results, or an *UploadError
+// listing the files that were not uploaded.
+func uploadedPaths(results []UploadResult) ([]string, error) {
+	paths := make([]string, 0, len(results))
+	var failed []FileError
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, FileError{
+				Filename:    result.Filename,
+				Size:        result.Size,
+				ContentType: result.ContentType,
+				Err:         result.Err,
+			})
+			continue
+		}
+		paths = append(paths, result.Src)
+	}
+	if len(failed) > 0 {
+		return nil, &UploadError{Files: failed}
+	}
+	return paths, nil
+}
+
+// multipartStream writes the multipart form holding files.
+type multipartStream struct {
+	ctx      context.Context
+	boundary string
+	files    []uploadFile
+	progress ProgressFunc
+
+	mu sync.Mutex
+	// tooBig is set when a file of unknown size exceeds MaxUploadSize while it is sent.
+	tooBig *FileError
+	// sent holds the number of bytes sent for each file by the last write.
+	sent []int64
+}
+
+// open returns a reader of the form, written on the fly by another goroutine. The copy stops when ctx is
+// done or when the reader is closed.
+func (s *multipartStream) open() io.ReadCloser {
+	pr, pw := io.Pipe()
+	stop := context.AfterFunc(s.ctx, func() {
+		pw.CloseWithError(s.ctx.Err())
+	})
+	go func() {
+		defer stop()
+		pw.CloseWithError(s.write(pw))
+	}()
+	return pr
+}
+
+// sentSizes returns the number of bytes sent for each file by the last write that sent all of them, or
+// the size of the files before any write.
+func (s *multipartStream) sentSizes() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sent != nil {
+		return s.sent
+	}
+	sizes := make([]int64, len(s.files))
+	for i, file := range s.files {
+		sizes[i] = file.size
+	}
+	return sizes
+}
+
+func (s *multipartStream) fileError() *FileError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tooBig
+}
+
+func (s *multipartStream) write(w io.Writer) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(s.boundary); err != nil {
+		return err
+	}
+
+	var progress *progressWriter
+	if s.progress != nil {
+		progress = &progressWriter{report: s.progress}
+		progress.TotalSize = s.totalSize()
+	}
+
+	sent := make([]int64, len(s.files))
+	for i, file := range s.files {
+		part, err := createPart(writer, file)
+		if err != nil {
+			return err
+		}
+		if progress != nil {
+			part = progress.start(part, i, file)
+		}
+
+		content, err := file.open()
+		if err != nil {
+			return err
+		}
+		// Read one byte more than the limit to find files that are too big.
+		n, err := io.Copy(part, io.LimitReader(&contextReader{ctx: s.ctx, r: content}, MaxUploadSize+1))
+		content.Close()
+		if err != nil {
+			return errors.Wrapf(err, "upload %s", file.name)
+		}
+		sent[i] = n
+		if n > MaxUploadSize {
+			ferr := &FileError{Filename: file.name, Size: n, ContentType: file.contentType, Err: ErrFileTooBig}
+			s.mu.Lock()
+			s.tooBig = ferr
+			s.mu.Unlock()
+			return ferr
+		}
+	}
+
+	// Record the sizes before the closing boundary, which lets the request complete.
+	s.mu.Lock()
+	s.sent = sent
+	s.mu.Unlock()
+
+	return writer.Close()
+}
+
+func (s *multipartStream) totalSize() int64 {
+	var total int64
+	for _, file := range s.files {
+		if file.size < 0 {
+			return -1
+		}
+		total += file.size
+	}
+	return total
+}
+
+// length returns the length of the form, -1 if the size of a file is unknown.
+func (s *multipartStream) length() int64 {
+	counter := &countingWriter{}
+	writer := multipart.NewWriter(counter)
+	if err := writer.SetBoundary(s.boundary); err != nil {
+		return -1
+	}
+
+	var length int64
+	for _, file := range s.files {
+		if file.size < 0 {
+			return -1
+		}
+		if _, err := createPart(writer, file); err != nil {
+			return -1
+		}
+		length += file.size
+	}
+	if err := writer.Close(); err != nil {
+		return -1
+	}
+
+	return length + counter.n
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// createPart starts the part of file with its media type. Its field name is a hash of the filename, as
+// Telegraph only needs distinct names.
+func createPart(writer *multipart.Writer, file uploadFile) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%x"; filename="%s"`,
+		sha256.Sum256([]byte(file.name)), quoteEscaper.Replace(file.name)))
+	h.Set("Content-Type", file.contentType)
+	return writer.CreatePart(h)
+}
+
+// progressWriter reports the bytes written to the part of each file.
+type progressWriter struct {
+	UploadProgress
+	w      io.Writer
+	report ProgressFunc
+}
+
+// start reports the beginning of a file and returns the writer counting its bytes.
+func (w *progressWriter) start(part io.Writer, i int, file uploadFile) io.Writer {
+	w.w = part
+	w.Filename = file.name
+	w.File = i
+	w.Sent = 0
+	w.Size = file.size
+	w.report(w.UploadProgress)
+	return w
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if n > 0 {
+		w.Sent += int64(n)
+		w.TotalSent += int64(n)
+		w.report(w.UploadProgress)
+	}
+	return n, err
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// contextReader stops reading when ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
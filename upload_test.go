@@ -0,0 +1,324 @@
+package telegraph
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// readUpload decodes the multipart form of an upload request into a map of base filenames to contents,
+// prefixed with the media type of their part.
+func readUpload(t *testing.T, req *http.Request) map[string]string {
+	t.Helper()
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.ContentLength > 0 && req.ContentLength != int64(len(data)) {
+		t.Errorf("content length %d, body of %d bytes", req.ContentLength, len(data))
+	}
+
+	files := map[string]string{}
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[part.FileName()] = part.Header.Get("Content-Type") + " " + string(content)
+	}
+}
+
+func TestUploadFiles(t *testing.T) {
+	dir := t.TempDir()
+	filenames := []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "b.png")}
+	contents := [][]byte{
+		append([]byte("\xff\xd8\xff"), bytes.Repeat([]byte{'a'}, 100_000)...),
+		append(pngHeader, 'b'),
+	}
+	for i, filename := range filenames {
+		if err := os.WriteFile(filename, contents[i], 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewClient("token")
+	c.Retry = &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	calls := 0
+	c.do = func(req *http.Request) (*http.Response, error) {
+		calls++
+		if req.ContentLength <= 0 {
+			t.Error("content length must be set for files")
+		}
+		files := readUpload(t, req)
+		if len(files) != 2 || files["a.jpg"] != "image/jpeg "+string(contents[0]) ||
+			files["b.png"] != "image/png "+string(contents[1]) {
+			t.Errorf("unexpected files in attempt %d", calls)
+		}
+
+		status, body := http.StatusOK, `[{"src":"/file/a.jpg"},{"src":"/file/b.png"}]`
+		if calls == 1 {
+			status, body = http.StatusBadGateway, "bad gateway"
+		}
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}
+
+	// The files are read again when the request is retried.
+	paths, err := c.UploadFiles(context.Background(), filenames, WithRetry(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(paths) != 2 || paths[1] != "/file/b.png" {
+		t.Errorf("unexpected paths after %d calls: %v", calls, paths)
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := c.UploadFiles(context.Background(), []string{filepath.Join(dir, "missing.jpg")}); !os.IsNotExist(err) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		text, big := filepath.Join(dir, "notes.txt"), filepath.Join(dir, "big.png")
+		if err := os.WriteFile(text, []byte("plain text"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(big, pngHeader, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(big, MaxUploadSize+1); err != nil {
+			t.Fatal(err)
+		}
+
+		calls = 0
+		_, err := c.UploadFiles(context.Background(), []string{filenames[0], text, big})
+		var uploadErr *UploadError
+		if !errors.As(err, &uploadErr) || len(uploadErr.Files) != 2 || calls != 0 {
+			t.Fatalf("unexpected error after %d calls: %v", calls, err)
+		}
+		if f := uploadErr.Files[0]; f.Filename != text || f.ContentType != "text/plain" || !errors.Is(f, ErrFileType) {
+			t.Errorf("unexpected file error: %#v", f)
+		}
+		if f := uploadErr.Files[1]; f.Filename != big || f.Size != MaxUploadSize+1 || !errors.Is(f, ErrFileTooBig) {
+			t.Errorf("unexpected file error: %#v", f)
+		}
+		if !errors.Is(err, ErrFileTooBig) {
+			t.Error("UploadError must match the errors of its files")
+		}
+	})
+
+	t.Run("short response", func(t *testing.T) {
+		c := NewClient("token")
+		c.do = func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`[{"src":"/file/a.jpg"}]`)),
+			}, nil
+		}
+
+		paths, err := c.UploadFiles(context.Background(), filenames)
+		var uploadErr *UploadError
+		if !errors.As(err, &uploadErr) || paths != nil {
+			t.Fatalf("unexpected result: %v, %v", paths, err)
+		}
+		if len(uploadErr.Files) != 1 || uploadErr.Files[0].Filename != filenames[1] || !errors.Is(err, ErrUploadMissing) {
+			t.Errorf("unexpected file errors: %#v", uploadErr.Files)
+		}
+	})
+}
+
+func TestUpload(t *testing.T) {
+	c := NewClient("token")
+	c.do = func(req *http.Request) (*http.Response, error) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`[{"src":"/file/a.png"}]`)),
+		}, nil
+	}
+
+	t.Run("type", func(t *testing.T) {
+		_, err := c.Upload(context.Background(), "a.png", strings.NewReader("<html>"))
+		if !errors.Is(err, ErrFileType) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		// The size of a reader without Len method is only known once it is sent.
+		content := io.MultiReader(bytes.NewReader(pngHeader), io.LimitReader(endlessReader{}, MaxUploadSize))
+		_, err := c.Upload(context.Background(), "big.png", content)
+		var uploadErr *UploadError
+		if !errors.As(err, &uploadErr) || uploadErr.Files[0].Size != MaxUploadSize+1 {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+// endlessReader never ends.
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func TestUploadCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewClient("token")
+	c.do = func(req *http.Request) (*http.Response, error) {
+		if _, err := io.CopyN(io.Discard, req.Body, 1<<20); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if _, err := io.Copy(io.Discard, req.Body); !errors.Is(err, context.Canceled) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return nil, ctx.Err()
+	}
+
+	content := io.MultiReader(bytes.NewReader(pngHeader), endlessReader{})
+	if _, err := c.Upload(ctx, "endless.png", content); !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUploadProgress(t *testing.T) {
+	dir := t.TempDir()
+	filenames := []string{filepath.Join(dir, "a.png"), filepath.Join(dir, "b.png")}
+	sizes := []int64{100_000, 10}
+	for i, filename := range filenames {
+		content := append(pngHeader, bytes.Repeat([]byte{'x'}, int(sizes[i])-len(pngHeader))...)
+		if err := os.WriteFile(filename, content, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewClient("token")
+	c.do = func(req *http.Request) (*http.Response, error) {
+		if _, err := io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`[{"src":"/file/a.png"},{"src":"/file/b.png"}]`)),
+		}, nil
+	}
+
+	var events []UploadProgress
+	if _, err := c.UploadFiles(context.Background(), filenames, WithProgress(func(p UploadProgress) {
+		events = append(events, p)
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make([]int64, len(filenames))
+	for _, p := range events {
+		if p.TotalSize != sizes[0]+sizes[1] || p.Size != sizes[p.File] || p.Filename != filenames[p.File] {
+			t.Fatalf("unexpected progress: %+v", p)
+		}
+		sent[p.File] = p.Sent
+	}
+	last := events[len(events)-1]
+	if sent[0] != sizes[0] || sent[1] != sizes[1] || last.TotalSent != last.TotalSize {
+		t.Errorf("upload not completed: %v, last progress: %+v", sent, last)
+	}
+
+	t.Run("unknown size", func(t *testing.T) {
+		var last UploadProgress
+		content := io.MultiReader(bytes.NewReader(pngHeader), strings.NewReader("data"))
+		if _, err := c.Upload(context.Background(), "a.png", content, WithProgress(func(p UploadProgress) {
+			last = p
+		})); err != nil {
+			t.Fatal(err)
+		}
+		if last.Sent != 12 || last.TotalSent != 12 || last.Size != -1 || last.TotalSize != -1 {
+			t.Errorf("unexpected progress: %+v", last)
+		}
+	})
+}
+
+func TestUploadFilesDetailed(t *testing.T) {
+	dir := t.TempDir()
+	valid, text := filepath.Join(dir, "a.png"), filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(valid, append(pngHeader, "data"...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(text, []byte("plain text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.png")
+
+	c := NewClient("token")
+	var sent map[string]string
+	c.do = func(req *http.Request) (*http.Response, error) {
+		sent = readUpload(t, req)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`[{"src":"/file/123.png"}]`)),
+		}, nil
+	}
+
+	results, err := c.UploadFilesDetailed(context.Background(), []string{text, valid, missing})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent["a.png"] == "" {
+		t.Errorf("unexpected files sent: %v", sent)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if r := results[0]; r.Filename != text || !errors.Is(r.Err, ErrFileType) || r.ContentType != "text/plain" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	expected := UploadResult{
+		Filename:    valid,
+		Src:         "/file/123.png",
+		URL:         "https://telegra.ph/file/123.png",
+		Size:        12,
+		ContentType: "image/png",
+	}
+	if r := results[1]; r != expected {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if r := results[2]; r.Filename != missing || !os.IsNotExist(r.Err) {
+		t.Errorf("unexpected result: %+v", r)
+	}
+
+	t.Run("reader", func(t *testing.T) {
+		result, err := c.UploadDetailed(context.Background(), "b.png", io.MultiReader(bytes.NewReader(pngHeader), strings.NewReader("xyz")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Size != 11 || result.Src != "/file/123.png" || result.ContentType != "image/png" {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package telegraph
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field limits documented by the Telegraph API.
+const (
+	MaxShortNameLength  = 32
+	MaxAuthorNameLength = 128
+	MaxAuthorURLLength  = 512
+	MaxTitleLength      = 256
+	MaxContentSize      = 64 * 1024
+	MaxPageListLimit    = 200
+)
+
+// MaxUploadSize is the size limit of the files accepted by telegra.ph/upload.
+const MaxUploadSize = 5 * 1024 * 1024
+
+// UploadContentTypes lists the media types of the files accepted by telegra.ph/upload.
+var UploadContentTypes = []string{"image/jpeg", "image/png", "image/gif", "video/mp4"}
+
+// FieldError describes a single field that does not fit the Telegraph limits.
+type FieldError struct {
+	// Field is the API name of the field, e.g. "short_name".
+	Field string
+	// Length is the measured length of the value, in Unit, or the value itself for numbers.
+	Length int
+	// Min and Max are the allowed bounds of Length.
+	Min, Max int
+	// Unit is "characters" for strings, "bytes" for the encoded page content and empty for numbers.
+	Unit string
+}
+
+func (e FieldError) Error() string {
+	if e.Unit == "" {
+		return fmt.Sprintf("%s must be between %d and %d, got %d", e.Field, e.Min, e.Max, e.Length)
+	}
+	return fmt.Sprintf("%s must be %d-%d %s long, got %d", e.Field, e.Min, e.Max, e.Unit, e.Length)
+}
+
+// ValidationError is returned before any HTTP call is made when parameters violate the Telegraph limits.
+// It lists every violated field.
+type ValidationError struct {
+	// Endpoint is the API method that was about to be called.
+	Endpoint string
+	Fields   []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Error())
+	}
+	return fmt.Sprintf("%s: invalid parameters: %s", e.Endpoint, strings.Join(msgs, "; "))
+}
+
+// validator collects field errors for a single call.
+type validator struct {
+	fields []FieldError
+}
+
+// length checks the number of characters of value. Telegraph counts Unicode code points, not bytes.
+func (v *validator) length(field, value string, minLen, maxLen int) {
+	v.check(field, utf8.RuneCountInString(value), minLen, maxLen, "characters")
+}
+
+// number checks that value is between minValue and maxValue.
+func (v *validator) number(field string, value, minValue, maxValue int) {
+	v.check(field, value, minValue, maxValue, "")
+}
+
+// content checks the size of the JSON-encoded page content.
+func (v *validator) content(nodes []Node, encoded []byte) {
+	size := len(encoded)
+	if len(nodes) == 0 {
+		size = 0
+	}
+	v.check("content", size, 1, MaxContentSize, "bytes")
+}
+
+func (v *validator) check(field string, length, minLen, maxLen int, unit string) {
+	if length >= minLen && length <= maxLen {
+		return
+	}
+	v.fields = append(v.fields, FieldError{
+		Field:  field,
+		Length: length,
+		Min:    minLen,
+		Max:    maxLen,
+		Unit:   unit,
+	})
+}
+
+func (v *validator) err(endpoint string) error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Endpoint: endpoint, Fields: v.fields}
+}
+
+func validateAuthor(v *validator, authorName, authorURL string) {
+	v.length("author_name", authorName, 0, MaxAuthorNameLength)
+	v.length("author_url", authorURL, 0, MaxAuthorURLLength)
+}
+
+func (p *CreateAccountParams) validate(shortName string) error {
+	v := new(validator)
+	v.length("short_name", shortName, 1, MaxShortNameLength)
+	if p != nil {
+		validateAuthor(v, p.AuthorName, p.AuthorURL)
+	}
+	return v.err("createAccount")
+}
+
+func (p *EditAccountInfoParams) validate() error {
+	if p == nil {
+		return nil
+	}
+	v := new(validator)
+	v.length("short_name", p.ShortName, 0, MaxShortNameLength)
+	validateAuthor(v, p.AuthorName, p.AuthorURL)
+	return v.err("editAccountInfo")
+}
+
+func (p *PageParams) validate(endpoint, title string, content []Node, encoded []byte) error {
+	v := new(validator)
+	v.length("title", title, 1, MaxTitleLength)
+	v.content(content, encoded)
+	if p != nil {
+		validateAuthor(v, p.AuthorName, p.AuthorURL)
+	}
+	return v.err(endpoint)
+}
+
+func (p *GetPageListParams) validate() error {
+	if p == nil {
+		return nil
+	}
+	v := new(validator)
+	v.number("offset", p.Offset, 0, math.MaxInt)
+	v.number("limit", p.Limit, 0, MaxPageListLimit)
+	return v.err("getPageList")
+}
+
+// viewsUnset marks the fields of a viewsPeriod that are not passed.
+const viewsUnset = math.MinInt
+
+// viewsPeriod is the period of a getViews call. Fields are set from the year down, each one requiring the
+// previous ones, and unset ones are viewsUnset.
+type viewsPeriod struct {
+	year, month, day, hour int
+}
+
+// viewsFields lists the getViews period fields with their ranges, from the year down.
+var viewsFields = []struct {
+	name     string
+	min, max int
+}{
+	{"year", 2000, 2100},
+	{"month", 1, 12},
+	{"day", 1, 31},
+	{"hour", 0, 24},
+}
+
+func (p viewsPeriod) values() []int {
+	return []int{p.year, p.month, p.day, p.hour}
+}
+
+// validate checks the ranges of the fields and that each set field has the previous ones set, reporting
+// unset required fields as zero.
+func (p viewsPeriod) validate() error {
+	values := p.values()
+	last := -1
+	for i, value := range values {
+		if value != viewsUnset {
+			last = i
+		}
+	}
+
+	v := new(validator)
+	for i := 0; i <= last; i++ {
+		value := values[i]
+		if value == viewsUnset {
+			value = 0
+		}
+		v.number(viewsFields[i].name, value, viewsFields[i].min, viewsFields[i].max)
+	}
+	return v.err("getViews")
+}
+
+// period returns the period of p. Zero fields are not passed, except the hour which is passed if not nil.
+func (p *GetViewsParams) period() viewsPeriod {
+	if p == nil {
+		return viewsPeriod{year: viewsUnset, month: viewsUnset, day: viewsUnset, hour: viewsUnset}
+	}
+	period := viewsPeriod{year: viewsField(p.Year), month: viewsField(p.Month), day: viewsField(p.Day), hour: viewsUnset}
+	if p.Hour != nil {
+		period.hour = int(*p.Hour)
+	}
+	return period
+}
+
+func viewsField(value int64) int {
+	if value <= 0 {
+		return viewsUnset
+	}
+	return int(value)
+}
@@ -0,0 +1,64 @@
+package telegraph
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestValidation(t *testing.T) {
+	c := NewClient("token")
+	// The stub runs within the subtests: sent requests are reported once they are done.
+	var sent []string
+	c.do = func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.URL.String())
+		return nil, errors.New("request must not be sent")
+	}
+	defer func() {
+		if len(sent) > 0 {
+			t.Errorf("requests must not be sent: %v", sent)
+		}
+	}()
+
+	t.Run("create account", func(t *testing.T) {
+		_, err := c.CreateAccount(context.Background(), "", &CreateAccountParams{
+			AuthorName: strings.Repeat("я", MaxAuthorNameLength+1),
+		})
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Fatalf("error must be a ValidationError, got: %v", err)
+		}
+		if len(vErr.Fields) != 2 || vErr.Fields[0].Field != "short_name" || vErr.Fields[1].Field != "author_name" {
+			t.Errorf("unexpected fields: %#v", vErr.Fields)
+		}
+		if vErr.Fields[1].Length != MaxAuthorNameLength+1 {
+			t.Errorf("length must be counted in characters, got: %d", vErr.Fields[1].Length)
+		}
+	})
+
+	t.Run("create page", func(t *testing.T) {
+		content := []Node{strings.Repeat("a", MaxContentSize)}
+		_, err := c.CreatePage(context.Background(), strings.Repeat("t", MaxTitleLength), content, nil)
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Fatalf("error must be a ValidationError, got: %v", err)
+		}
+		if len(vErr.Fields) != 1 || vErr.Fields[0].Field != "content" || vErr.Fields[0].Unit != "bytes" {
+			t.Errorf("unexpected fields: %#v", vErr.Fields)
+		}
+	})
+
+	t.Run("edit page without content", func(t *testing.T) {
+		_, err := c.EditPage(context.Background(), "Sample-Page-12-15", "", nil, nil)
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Fatalf("error must be a ValidationError, got: %v", err)
+		}
+		if vErr.Endpoint != "editPage" || len(vErr.Fields) != 2 {
+			t.Errorf("unexpected error: %v", vErr)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package telegraph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultViewsConcurrency is the number of GetViews calls ViewsSeries makes at once by default.
+const DefaultViewsConcurrency = 4
+
+// MaxViewsSeriesPoints is the number of points, and so of GetViews calls, ViewsSeries accepts. It covers a
+// year of hours.
+const MaxViewsSeriesPoints = 366 * 24
+
+// ViewsInterval is the duration of the points of a views series.
+type ViewsInterval uint8
+
+const (
+	// ViewsByDay counts the views per day, the default.
+	ViewsByDay ViewsInterval = iota
+	// ViewsByHour counts the views per hour.
+	ViewsByHour
+	// ViewsByMonth counts the views per month.
+	ViewsByMonth
+)
+
+// ViewsSeriesParams configures ViewsSeries. The zero value uses the defaults.
+type ViewsSeriesParams struct {
+	// Interval is the duration of the points, ViewsByDay by default.
+	Interval ViewsInterval
+
+	// Concurrency is the number of calls made at once, DefaultViewsConcurrency if not positive.
+	Concurrency int
+}
+
+// ViewsPoint is the number of views of a page during an interval.
+type ViewsPoint struct {
+	// Time is the start of the interval, in UTC.
+	Time  time.Time
+	Views int
+}
+
+// ViewsSeries returns the number of views of the page at path per interval, from the interval holding
+// from up to to, excluded. Intervals are in UTC and the series is dense: intervals without views are
+// included with zero views. One GetViews call is made per interval, with the year, month, day and hour
+// fields the interval requires, and opts.
+//
+// The periods are validated before any call is made, and ranges of more than MaxViewsSeriesPoints
+// intervals are rejected. The first failed call cancels the others and its error is returned.
+func (c *Client) ViewsSeries(ctx context.Context, path string, from, to time.Time, params *ViewsSeriesParams, opts ...RequestOption) ([]ViewsPoint, error) {
+	if params == nil {
+		params = &ViewsSeriesParams{}
+	}
+	if !from.Before(to) {
+		return nil, errors.Errorf("views series: from %s is not before to %s", from, to)
+	}
+
+	var points []ViewsPoint
+	var periods []viewsPeriod
+	for at := params.Interval.truncate(from.UTC()); at.Before(to); at = params.Interval.next(at) {
+		if len(points) == MaxViewsSeriesPoints {
+			return nil, errors.Errorf("views series: more than %d intervals from %s to %s", MaxViewsSeriesPoints, from, to)
+		}
+		period := params.Interval.period(at)
+		if err := period.validate(); err != nil {
+			return nil, err
+		}
+		points = append(points, ViewsPoint{Time: at})
+		periods = append(periods, period)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, params.concurrency())
+
+	for i, period := range periods {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			views, err := c.getViews(ctx, path, period, opts...)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			points[i].Views = views.Views
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func (p *ViewsSeriesParams) concurrency() int {
+	if p.Concurrency > 0 {
+		return p.Concurrency
+	}
+	return DefaultViewsConcurrency
+}
+
+// truncate returns the start of the interval holding t.
+func (i ViewsInterval) truncate(t time.Time) time.Time {
+	switch i {
+	case ViewsByHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.UTC)
+	case ViewsByMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// next returns the start of the interval following the one starting at t.
+func (i ViewsInterval) next(t time.Time) time.Time {
+	switch i {
+	case ViewsByHour:
+		return t.Add(time.Hour)
+	case ViewsByMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// period returns the getViews period of the interval starting at t. Hours need the day, which needs the
+// month, which needs the year.
+func (i ViewsInterval) period(t time.Time) viewsPeriod {
+	period := viewsPeriod{year: t.Year(), month: int(t.Month()), day: t.Day(), hour: t.Hour()}
+	switch i {
+	case ViewsByHour:
+	case ViewsByMonth:
+		period.day, period.hour = viewsUnset, viewsUnset
+	default:
+		period.hour = viewsUnset
+	}
+	return period
+}
@@ -0,0 +1,189 @@
+package telegraph
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// stubViews answers getViews calls with views computed from the requested period by views, and records
+// the forms of the calls.
+func stubViews(c *Client, views func(form url.Values) int) func() []url.Values {
+	var mu sync.Mutex
+	var forms []url.Values
+	c.do = func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		forms = append(forms, form)
+		mu.Unlock()
+
+		data := fmt.Sprintf(`{"ok":true,"result":{"views":%d}}`, views(form))
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(data))}, nil
+	}
+
+	return func() []url.Values {
+		mu.Lock()
+		defer mu.Unlock()
+		return forms
+	}
+}
+
+func TestGetViewsParams(t *testing.T) {
+	hour := func(h int64) *int64 { return &h }
+	c := NewClient("")
+	forms := stubViews(c, func(url.Values) int { return 1 })
+	ctx := context.Background()
+
+	if _, err := c.GetViews(ctx, "Page-12-15", &GetViewsParams{Year: 2024, Month: 12, Day: 15, Hour: hour(10)}); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"year": {"2024"}, "month": {"12"}, "day": {"15"}, "hour": {"10"}}
+	if got := forms()[0]; got.Encode() != want.Encode() {
+		t.Errorf("got form %v, want %v", got, want)
+	}
+
+	if _, err := c.GetViews(ctx, "Page-12-15", &GetViewsParams{Year: 2024, Month: 12, Day: 15, Hour: hour(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if got := forms()[1]; got.Get("hour") != "0" {
+		t.Errorf("hour 0 must be sent, got %v", got)
+	}
+
+	tests := []struct {
+		params *GetViewsParams
+		fields []FieldError
+	}{
+		{&GetViewsParams{Year: 1999}, []FieldError{{Field: "year", Length: 1999, Min: 2000, Max: 2100}}},
+		{&GetViewsParams{Year: 2024, Month: 13}, []FieldError{{Field: "month", Length: 13, Min: 1, Max: 12}}},
+		{&GetViewsParams{Day: 1, Hour: hour(25)}, []FieldError{
+			{Field: "year", Length: 0, Min: 2000, Max: 2100},
+			{Field: "month", Length: 0, Min: 1, Max: 12},
+			{Field: "hour", Length: 25, Min: 0, Max: 24},
+		}},
+	}
+	for _, tt := range tests {
+		_, err := c.GetViews(ctx, "Page-12-15", tt.params)
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Errorf("%+v: error must be a ValidationError, got: %v", tt.params, err)
+			continue
+		}
+		if fmt.Sprint(vErr.Fields) != fmt.Sprint(tt.fields) {
+			t.Errorf("%+v: got fields %v, want %v", tt.params, vErr.Fields, tt.fields)
+		}
+	}
+	if calls := len(forms()); calls != 2 {
+		t.Errorf("invalid parameters must not be sent, got %d calls", calls)
+	}
+}
+
+func TestViewsSeries(t *testing.T) {
+	ctx := context.Background()
+	// The stub counts as many views as the hour, or the day, or the month.
+	byPeriod := func(form url.Values) int {
+		for _, field := range []string{"hour", "day", "month"} {
+			if form.Has(field) {
+				n, _ := strconv.Atoi(form.Get(field))
+				return n
+			}
+		}
+		return 0
+	}
+
+	t.Run("hours", func(t *testing.T) {
+		c := NewClient("")
+		forms := stubViews(c, byPeriod)
+
+		from := time.Date(2024, 12, 31, 22, 30, 0, 0, time.UTC)
+		to := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+		points, err := c.ViewsSeries(ctx, "Page-12-15", from, to, &ViewsSeriesParams{Interval: ViewsByHour, Concurrency: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []ViewsPoint{
+			{time.Date(2024, 12, 31, 22, 0, 0, 0, time.UTC), 22},
+			{time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), 23},
+			{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 0},
+			{time.Date(2025, 1, 1, 1, 0, 0, 0, time.UTC), 1},
+		}
+		if fmt.Sprint(points) != fmt.Sprint(want) {
+			t.Errorf("got %v, want %v", points, want)
+		}
+
+		sent := false
+		for _, form := range forms() {
+			if form.Encode() == "day=1&hour=0&month=1&year=2025" {
+				sent = true
+			}
+		}
+		if !sent {
+			t.Errorf("hour 0 must be sent with its day, month and year, got %v", forms())
+		}
+	})
+
+	t.Run("days and months", func(t *testing.T) {
+		c := NewClient("")
+		forms := stubViews(c, byPeriod)
+
+		from := time.Date(2024, 2, 28, 12, 0, 0, 0, time.FixedZone("UTC+3", 3*3600))
+		to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+		points, err := c.ViewsSeries(ctx, "Page-12-15", from, to, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 3 || points[0].Views != 28 || points[1].Views != 29 || points[2].Views != 1 {
+			t.Errorf("unexpected days: %v", points)
+		}
+
+		points, err = c.ViewsSeries(ctx, "Page-12-15", from, to, &ViewsSeriesParams{Interval: ViewsByMonth})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 2 || points[0].Views != 2 || points[1].Views != 3 {
+			t.Errorf("unexpected months: %v", points)
+		}
+		if form := forms()[len(forms())-1]; form.Has("day") {
+			t.Errorf("months must not send the day, got %v", form)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		c := NewClient("")
+		// stubResponses is not safe for concurrent calls.
+		stubResponses(c, `{"ok":false,"error":"PAGE_NOT_FOUND"}`)
+
+		from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+		_, err := c.ViewsSeries(ctx, "Missing-12-15", from, from.AddDate(0, 0, 10), &ViewsSeriesParams{Concurrency: 1})
+		if !IsNotFound(err) {
+			t.Errorf("expected the call error, got: %v", err)
+		}
+
+		_, err = c.ViewsSeries(ctx, "Page-12-15", from.AddDate(-30, 0, 0), from, &ViewsSeriesParams{Interval: ViewsByMonth})
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Errorf("periods must be validated, got: %v", err)
+		}
+
+		_, err = c.ViewsSeries(ctx, "Page-12-15", from.AddDate(-2, 0, 0), from, &ViewsSeriesParams{Interval: ViewsByHour})
+		if err == nil || !strings.Contains(err.Error(), "more than") {
+			t.Errorf("long ranges must be rejected, got: %v", err)
+		}
+	})
+}
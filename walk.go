@@ -0,0 +1,151 @@
+package telegraph
+
+import (
+	"iter"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk. If the result visitor w is not
+// nil, Walk visits each of the children of the node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(n Node) (w Visitor)
+}
+
+// Walk traverses nodes in depth-first order. Text nodes are strings; elements are passed as they are
+// found in the tree, *NodeElement, NodeElement or the map[string]any produced by encoding/json, and the
+// children of all of them are visited.
+func Walk(v Visitor, nodes []Node) {
+	for _, n := range nodes {
+		walk(v, n)
+	}
+}
+
+func walk(v Visitor, n Node) {
+	if v = v.Visit(n); v == nil {
+		return
+	}
+	if element, ok := asElement(n); ok {
+		for _, child := range element.Children {
+			walk(v, child)
+		}
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(n Node) Visitor {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses nodes in depth-first order: it starts by calling f(n) for each node; if f returns
+// true, Inspect invokes f recursively for each of the children of n, followed by a call of f(nil).
+func Inspect(nodes []Node, f func(Node) bool) {
+	Walk(inspector(f), nodes)
+}
+
+// Descendants returns an iterator over nodes and all their descendants in depth-first order.
+func Descendants(nodes []Node) iter.Seq[Node] {
+	return func(yield func(Node) bool) {
+		descendants(nodes, yield)
+	}
+}
+
+func descendants(nodes []Node, yield func(Node) bool) bool {
+	for _, n := range nodes {
+		if !yield(n) {
+			return false
+		}
+		if element, ok := asElement(n); ok && !descendants(element.Children, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Elements returns an iterator over the elements among nodes and all their descendants in depth-first
+// order. Elements decoded as map[string]any are yielded as a *NodeElement copy, so changes to them are
+// only seen for *NodeElement trees.
+func Elements(nodes []Node) iter.Seq[*NodeElement] {
+	return func(yield func(*NodeElement) bool) {
+		for n := range Descendants(nodes) {
+			if element, ok := asElement(n); ok && !yield(element) {
+				return
+			}
+		}
+	}
+}
+
+// Action defines what Transform does with a node.
+type Action uint8
+
+const (
+	// ActionKeep keeps the node and transforms its children.
+	ActionKeep Action = iota
+	// ActionReplace replaces the node with the returned node, which is not transformed further.
+	ActionReplace
+	// ActionRemove removes the node with all its content.
+	ActionRemove
+	// ActionUnwrap replaces an element with its children, which are transformed in turn. Text nodes are
+	// kept.
+	ActionUnwrap
+	// ActionInsertBefore inserts the returned node before the node, which is kept as with ActionKeep.
+	ActionInsertBefore
+	// ActionInsertAfter inserts the returned node after the node, which is kept as with ActionKeep.
+	ActionInsertAfter
+)
+
+// Transform returns a copy of nodes rewritten by f. f is called for each node before its children and
+// the returned Action tells what to do with the node; the returned node is only used by ActionReplace,
+// ActionInsertBefore and ActionInsertAfter. Inserted and replacing nodes are not passed to f.
+//
+// The input tree is not modified: kept elements are returned as new *NodeElement values sharing their
+// attributes with the original ones, whatever their representation in the input.
+func Transform(nodes []Node, f func(Node) (Node, Action)) []Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	out := make([]Node, 0, len(nodes))
+	for _, n := range nodes {
+		out = transform(out, n, f)
+	}
+	return out
+}
+
+func transform(out []Node, n Node, f func(Node) (Node, Action)) []Node {
+	result, action := f(n)
+
+	switch action {
+	case ActionReplace:
+		return append(out, result)
+	case ActionRemove:
+		return out
+	case ActionUnwrap:
+		element, ok := asElement(n)
+		if !ok {
+			return append(out, n)
+		}
+		for _, child := range element.Children {
+			out = transform(out, child, f)
+		}
+		return out
+	case ActionInsertBefore:
+		out = append(out, result)
+	}
+
+	if element, ok := asElement(n); ok {
+		n = &NodeElement{
+			Tag:      element.Tag,
+			Attrs:    element.Attrs,
+			Children: Transform(element.Children, f),
+		}
+	}
+	out = append(out, n)
+
+	if action == ActionInsertAfter {
+		out = append(out, result)
+	}
+	return out
+}
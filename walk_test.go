@@ -0,0 +1,102 @@
+package telegraph
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var walkContent = []Node{
+	P("Hello, ", A("https://telegra.ph/", B("World")), "!"),
+	Figure(Img("/file/cat.jpg"), "cat"),
+}
+
+func TestInspect(t *testing.T) {
+	var tags []string
+	Inspect(walkContent, func(n Node) bool {
+		if element, ok := asElement(n); ok {
+			tags = append(tags, element.Tag)
+		}
+		// The children of links are skipped.
+		return n == nil || !reflect.DeepEqual(n, walkContent[0].(*NodeElement).Children[1])
+	})
+
+	if expected := []string{"p", "a", "figure", "img", "figcaption"}; !slices.Equal(tags, expected) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	data, err := json.Marshal(walkContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Maps decoded by encoding/json are walked as well.
+	var decoded []Node
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nodes := range [][]Node{walkContent, decoded} {
+		var texts []string
+		for n := range Descendants(nodes) {
+			if text, ok := n.(string); ok {
+				texts = append(texts, text)
+			}
+		}
+		if expected := []string{"Hello, ", "World", "!", "cat"}; !slices.Equal(texts, expected) {
+			t.Errorf("unexpected texts: %v", texts)
+		}
+
+		var srcs []string
+		for element := range Elements(nodes) {
+			if src := element.Attrs["src"]; src != "" {
+				srcs = append(srcs, src)
+			}
+			if len(srcs) == 1 {
+				break
+			}
+		}
+		if !slices.Equal(srcs, []string{"/file/cat.jpg"}) {
+			t.Errorf("unexpected sources: %v", srcs)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	nodes := Transform(walkContent, func(n Node) (Node, Action) {
+		element, ok := asElement(n)
+		if !ok {
+			return nil, ActionKeep
+		}
+		switch element.Tag {
+		case "a":
+			return nil, ActionUnwrap
+		case "b":
+			return Em(element.Children...), ActionReplace
+		case "figcaption":
+			return nil, ActionRemove
+		case "figure":
+			return Hr(), ActionInsertBefore
+		case "img":
+			return Code("image"), ActionInsertAfter
+		}
+		return nil, ActionKeep
+	})
+
+	var sb strings.Builder
+	if err := RenderHTML(&sb, nodes); err != nil {
+		t.Fatal(err)
+	}
+	expected := `<p>Hello, <em>World</em>!</p><hr><figure><img src="/file/cat.jpg"><code>image</code></figure>`
+	if sb.String() != expected {
+		t.Errorf("unexpected html:\ngot:  %s\nwant: %s", sb.String(), expected)
+	}
+
+	// The input is left untouched.
+	if link := walkContent[0].(*NodeElement).Children[1].(*NodeElement); link.Tag != "a" {
+		t.Errorf("input modified: %#v", link)
+	}
+}